@@ -36,6 +36,15 @@ func (a *App) initCache() error {
 		opts = append(opts, cache.KeyPrefix(conf.KeyPrefix))
 	}
 
+	if conf.Startup.Wait {
+		opts = append(opts, cache.WaitForConnection{
+			Timeout:     conf.Startup.Timeout,
+			MaxAttempts: conf.Startup.MaxAttempts,
+			Backoff:     conf.Startup.Backoff,
+			MaxBackoff:  conf.Startup.MaxBackoff,
+		})
+	}
+
 	a.cache = cache.New(opts...)
 
 	return a.cache.Start(a.BackgroundContext())
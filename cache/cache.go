@@ -9,7 +9,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"sync"
 
+	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -38,10 +41,14 @@ func (e KeyNotFoundError) Error() string {
 
 // Cache represents a cache.
 type Cache struct {
-	options     []Option
-	cache       map[string]any
-	redisCon    redis.Cmdable
-	redisConStr string
+	options       []Option
+	lock          sync.RWMutex
+	cache         map[string]any
+	redisCon      redis.Cmdable
+	conStr        string
+	redisRouting  redisRouting
+	redisReplicas *redisReplicas
+	memcachedCon  *memcache.Client
 }
 
 // New creates a new cache with specified type.
@@ -81,6 +88,31 @@ func (c *Cache) Start(ctx context.Context) error {
 
 	finish := opt.Instrumenter.Observe(ctx, InstrumentationStart)
 
+	if opt.Type == MemcachedCache {
+		con, err := newMemcachedClient(opt.ConnectionString)
+		if err != nil {
+			finish(err)
+
+			return err
+		}
+
+		c.memcachedCon = con
+		c.conStr = opt.ConnectionString
+
+		if opt.WaitForConnection != nil {
+			if err := c.waitForConnection(ctx, opt); err != nil {
+				c.closeMemcached()
+				finish(err)
+
+				return err
+			}
+		}
+
+		finish(nil)
+
+		return nil
+	}
+
 	if opt.Type != RedisCache && opt.Type != RedisClusterCache && opt.Type != RedisSentinelCache {
 		finish(nil)
 
@@ -96,10 +128,13 @@ func (c *Cache) Start(ctx context.Context) error {
 	switch opt.Type {
 	case RedisCache:
 		con, err = newRedisClient(opt.ConnectionString, opt.ConnectionPassword)
+		if err == nil {
+			c.redisReplicas, err = newRedisReplicaClients(opt.ConnectionString, opt.ConnectionPassword)
+		}
 	case RedisClusterCache:
-		con, err = newRedisClusterClient(opt.ConnectionString, opt.ConnectionPassword)
+		con, err = newRedisClusterClient(opt.ConnectionString, opt.ConnectionPassword, opt.redisRouting())
 	case RedisSentinelCache:
-		con, err = newRedisSentinelClient(opt.ConnectionString, opt.ConnectionPassword)
+		con, err = newRedisSentinelClient(opt.ConnectionString, opt.ConnectionPassword, opt.redisRouting())
 	}
 
 	if err != nil {
@@ -109,15 +144,48 @@ func (c *Cache) Start(ctx context.Context) error {
 	}
 
 	c.redisCon = con
-	c.redisConStr = opt.ConnectionString
+	c.conStr = opt.ConnectionString
+	c.redisRouting = opt.redisRouting()
 
 	if opt.Logger != nil {
 		setRedisLogger(opt.Logger)
 	}
 
+	if opt.WaitForConnection != nil {
+		if err := c.waitForConnection(ctx, opt); err != nil {
+			c.closeRedis()
+			finish(err)
+
+			return err
+		}
+	}
+
+	finish(nil)
+
 	return nil
 }
 
+func (c *Cache) closeRedis() {
+	// Sentinel client can be either a failover or a cluster client
+	// depending on the read routing settings.
+	if v, ok := c.redisCon.(io.Closer); ok {
+		_ = v.Close()
+	}
+
+	_ = c.redisReplicas.Close()
+
+	c.redisCon = nil
+	c.redisReplicas = nil
+}
+
+func (c *Cache) closeMemcached() {
+	if c.memcachedCon != nil {
+		_ = c.memcachedCon.Close()
+	}
+
+	c.memcachedCon = nil
+}
+
 // Close cache and all its instances.
 func (c *Cache) Close() {
 	opt := newCacheOptions(c.options...)
@@ -126,22 +194,17 @@ func (c *Cache) Close() {
 	defer finish(nil)
 
 	switch opt.Type {
-	case RedisCache, RedisSentinelCache:
-		if v, ok := c.redisCon.(*redis.Client); ok {
-			_ = v.Close()
-		}
-
-		c.redisCon = nil
-	case RedisClusterCache:
-		if v, ok := c.redisCon.(*redis.ClusterClient); ok {
-			_ = v.Close()
-		}
-
-		c.redisCon = nil
-	case MemoryCache:
+	case RedisCache, RedisClusterCache, RedisSentinelCache:
+		c.closeRedis()
+	case MemcachedCache:
+		c.closeMemcached()
+	case MemoryCache, NoneCache:
 		// nothing to close
 	}
 
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	for _, i := range c.cache {
 		if c, ok := i.(InstanceCloser); ok {
 			c.Close()
@@ -157,14 +220,15 @@ func (c *Cache) Ping(ctx context.Context) error {
 
 	finish := opt.Instrumenter.Observe(ctx, InstrumentationPing)
 
-	if (opt.Type == RedisCache || opt.Type == RedisClusterCache) && c.redisCon != nil {
-		if s := c.redisCon.Ping(ctx); s != nil && s.Err() != nil {
-			finish(s.Err())
+	if err := c.pingBackend(ctx); err != nil {
+		finish(err)
 
-			return s.Err()
-		}
+		return err
 	}
 
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
 	for _, i := range c.cache {
 		if c, ok := i.(InstancePinger); ok {
 			if err := c.Ping(ctx); err != nil {
@@ -182,6 +246,13 @@ func (c *Cache) Ping(ctx context.Context) error {
 
 // Get returns pre-configured cache instance by name.
 func Get[T any](cache *Cache, name string) (Instance[T], error) {
+	cache.lock.RLock()
+	defer cache.lock.RUnlock()
+
+	return get[T](cache, name)
+}
+
+func get[T any](cache *Cache, name string) (Instance[T], error) {
 	i, ok := cache.cache[name]
 	if !ok {
 		return nil, errors.New("cache not found")
@@ -197,6 +268,30 @@ func Get[T any](cache *Cache, name string) (Instance[T], error) {
 
 // Create new cache instance with specified name and options.
 func Create[T any](cache *Cache, name string, opts ...Option) (Instance[T], error) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	return create[T](cache, name, opts...)
+}
+
+// GetOrCreate returns cache instance by name or creates a new one with specified
+// options if it does not exist yet.
+func GetOrCreate[T any](cache *Cache, name string, opts ...Option) (Instance[T], error) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	if _, ok := cache.cache[name]; ok {
+		return get[T](cache, name)
+	}
+
+	return create[T](cache, name, opts...)
+}
+
+func create[T any](cache *Cache, name string, opts ...Option) (Instance[T], error) {
+	if cache.cache == nil {
+		return nil, ErrCacheClosed
+	}
+
 	opt := append(append([]Option{}, cache.options...), opts...)
 
 	o := newCacheOptions(opt...)
@@ -212,36 +307,54 @@ func Create[T any](cache *Cache, name string, opts ...Option) (Instance[T], erro
 		if err != nil {
 			return nil, err
 		}
+	case NoneCache:
+		c = newNoneCache[T](opt...)
 	case RedisCache:
-		con := cache.redisCon
-		if o.ConnectionString != cache.redisConStr {
+		con, replicas := cache.redisCon, cache.redisReplicas
+		if o.ConnectionString != cache.conStr {
 			con, err = newRedisClient(o.ConnectionString, o.ConnectionPassword)
 			if err != nil {
 				return nil, err
 			}
+
+			replicas, err = newRedisReplicaClients(o.ConnectionString, o.ConnectionPassword)
+			if err != nil {
+				return nil, err
+			}
 		}
 
-		c = newRedisCache[T](name, con, opt...)
+		c = newRedisCache[T](name, con, replicas, opt...)
 	case RedisClusterCache:
 		con := cache.redisCon
-		if o.ConnectionString != cache.redisConStr {
-			con, err = newRedisClusterClient(o.ConnectionString, o.ConnectionPassword)
+		// Read routing is a connection setting so new connection is needed if it differs.
+		if o.ConnectionString != cache.conStr || o.redisRouting() != cache.redisRouting {
+			con, err = newRedisClusterClient(o.ConnectionString, o.ConnectionPassword, o.redisRouting())
 			if err != nil {
 				return nil, err
 			}
 		}
 
-		c = newRedisCache[T](name, con, opt...)
+		c = newRedisCache[T](name, con, nil, opt...)
 	case RedisSentinelCache:
 		con := cache.redisCon
-		if o.ConnectionString != cache.redisConStr {
-			con, err = newRedisSentinelClient(o.ConnectionString, o.ConnectionPassword)
+		if o.ConnectionString != cache.conStr || o.redisRouting() != cache.redisRouting {
+			con, err = newRedisSentinelClient(o.ConnectionString, o.ConnectionPassword, o.redisRouting())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		c = newRedisCache[T](name, con, nil, opt...)
+	case MemcachedCache:
+		con := cache.memcachedCon
+		if o.ConnectionString != cache.conStr {
+			con, err = newMemcachedClient(o.ConnectionString)
 			if err != nil {
 				return nil, err
 			}
 		}
 
-		c = newRedisCache[T](name, con, opt...)
+		c = newMemcachedCache[T](name, con, opt...)
 	}
 
 	if c != nil {
@@ -255,7 +368,7 @@ func Create[T any](cache *Cache, name string, opts ...Option) (Instance[T], erro
 
 // ValidateConnectionString validates connection string for specific cache type.
 func ValidateConnectionString(typ Type, connStr string) error {
-	if typ == MemoryCache {
+	if typ == MemoryCache || typ == NoneCache {
 		return nil
 	}
 
@@ -265,14 +378,18 @@ func ValidateConnectionString(typ Type, connStr string) error {
 
 	var err error
 
-	//nolint:exhaustive // memory cache type require no validation
+	//nolint:exhaustive // memory and none cache types require no validation
 	switch typ {
 	case RedisCache:
-		_, err = ParseRedisURL(connStr)
+		if _, err = ParseRedisURL(connStr); err == nil {
+			_, err = ParseRedisReplicaURLs(connStr)
+		}
 	case RedisClusterCache:
 		_, err = ParseRedisClusterURL(connStr)
 	case RedisSentinelCache:
 		_, err = ParseRedisSentinelURL(connStr)
+	case MemcachedCache:
+		_, err = ParseMemcachedURL(connStr)
 	default:
 		return fmt.Errorf("unsupported cache type: %v", typ)
 	}
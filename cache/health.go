@@ -0,0 +1,192 @@
+// Copyright 2022 Azugo. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultWaitBackoff    = 500 * time.Millisecond
+	defaultWaitMaxBackoff = 10 * time.Second
+)
+
+// PoolStats contains cache backend connection pool statistics.
+type PoolStats struct {
+	// Hits is the number of times free connection was found in the pool.
+	Hits uint32 `json:"hits"`
+	// Misses is the number of times free connection was not found in the pool.
+	Misses uint32 `json:"misses"`
+	// Timeouts is the number of times a wait for connection timed out.
+	Timeouts uint32 `json:"timeouts"`
+	// TotalConns is the number of total connections in the pool.
+	TotalConns uint32 `json:"total_conns"`
+	// IdleConns is the number of idle connections in the pool.
+	IdleConns uint32 `json:"idle_conns"`
+	// StaleConns is the number of stale connections removed from the pool.
+	StaleConns uint32 `json:"stale_conns"`
+}
+
+func (s *PoolStats) add(o *PoolStats) {
+	s.Hits += o.Hits
+	s.Misses += o.Misses
+	s.Timeouts += o.Timeouts
+	s.TotalConns += o.TotalConns
+	s.IdleConns += o.IdleConns
+	s.StaleConns += o.StaleConns
+}
+
+// Health represents cache backend health status.
+type Health struct {
+	// Type of the cache.
+	Type Type `json:"type"`
+	// Healthy is true if cache backend and all its instances are reachable.
+	Healthy bool `json:"healthy"`
+	// Error contains the error message if cache is not healthy.
+	Error string `json:"error,omitempty"`
+	// Latency of the health check.
+	Latency time.Duration `json:"latency"`
+	// Pool contains connection pool statistics for the primary connection.
+	Pool *PoolStats `json:"pool,omitempty"`
+	// ReplicaPool contains combined connection pool statistics for the replica connections.
+	ReplicaPool *PoolStats `json:"replica_pool,omitempty"`
+}
+
+// Health checks cache backend health and returns its status along with connection pool statistics.
+func (c *Cache) Health(ctx context.Context) Health {
+	opt := newCacheOptions(c.options...)
+
+	h := Health{
+		Type: opt.Type,
+	}
+
+	start := time.Now()
+	err := c.Ping(ctx)
+	h.Latency = time.Since(start)
+
+	h.Healthy = err == nil
+	if err != nil {
+		h.Error = err.Error()
+	}
+
+	h.Pool = redisPoolStats(c.redisCon)
+
+	if c.redisReplicas != nil && len(c.redisReplicas.clients) > 0 {
+		h.ReplicaPool = &PoolStats{}
+
+		for _, r := range c.redisReplicas.clients {
+			h.ReplicaPool.add(redisPoolStats(r))
+		}
+	}
+
+	return h
+}
+
+func redisPoolStats(con redis.Cmdable) *PoolStats {
+	p, ok := con.(interface{ PoolStats() *redis.PoolStats })
+	if !ok {
+		return nil
+	}
+
+	s := p.PoolStats()
+
+	return &PoolStats{
+		Hits:       s.Hits,
+		Misses:     s.Misses,
+		Timeouts:   s.Timeouts,
+		TotalConns: s.TotalConns,
+		IdleConns:  s.IdleConns,
+		StaleConns: s.StaleConns,
+	}
+}
+
+// pingBackend pings cache backend primary and all replica connections.
+func (c *Cache) pingBackend(ctx context.Context) error {
+	if c.memcachedCon != nil {
+		if err := c.memcachedCon.Ping(); err != nil {
+			return err
+		}
+	}
+
+	if c.redisCon == nil {
+		return nil
+	}
+
+	if err := c.redisCon.Ping(ctx).Err(); err != nil {
+		return err
+	}
+
+	if c.redisReplicas != nil {
+		for _, r := range c.redisReplicas.clients {
+			if err := r.Ping(ctx).Err(); err != nil {
+				return fmt.Errorf("replica %s: %w", r.Options().Addr, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// waitForConnection waits until cache backend is reachable.
+func (c *Cache) waitForConnection(ctx context.Context, opt *cacheOptions) error {
+	w := opt.WaitForConnection
+
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+
+	backoff := w.Backoff
+	if backoff <= 0 {
+		backoff = defaultWaitBackoff
+	}
+
+	maxBackoff := w.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultWaitMaxBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.pingBackend(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if w.MaxAttempts > 0 && attempt >= w.MaxAttempts {
+			return fmt.Errorf("cache backend not reachable after %d attempts: %w", attempt, err)
+		}
+
+		// Randomize delay to avoid all service instances reconnecting at the same time.
+		delay := backoff/2 + rand.N(backoff/2+1) //nolint:gosec
+
+		if opt.Logger != nil {
+			opt.Logger.Warn("cache backend not reachable, retrying",
+				zap.Int("attempt", attempt),
+				zap.Duration("delay", delay),
+				zap.Error(err))
+		}
+
+		t := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+
+			return errors.Join(fmt.Errorf("cache backend not reachable: %w", err), ctx.Err())
+		case <-t.C:
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
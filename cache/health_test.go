@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-quicktest/qt"
+)
+
+func TestMemoryCacheHealth(t *testing.T) {
+	c := New(MemoryCache)
+	err := c.Start(context.TODO())
+	qt.Assert(t, qt.IsNil(err))
+	defer c.Close()
+
+	h := c.Health(context.TODO())
+	qt.Check(t, qt.Equals(h.Type, MemoryCache))
+	qt.Check(t, qt.IsTrue(h.Healthy))
+	qt.Check(t, qt.IsNil(h.Pool))
+}
+
+func TestRedisCacheWaitForConnection(t *testing.T) {
+	c := New(RedisCache, ConnectionString("redis://127.0.0.1:1/0?max_retries=-1"), WaitForConnection{
+		MaxAttempts: 3,
+		Backoff:     10 * time.Millisecond,
+	})
+
+	start := time.Now()
+	err := c.Start(context.TODO())
+	qt.Assert(t, qt.ErrorMatches(err, `cache backend not reachable after 3 attempts: .*`))
+	qt.Check(t, qt.IsTrue(time.Since(start) >= 15*time.Millisecond))
+	qt.Check(t, qt.IsNil(c.redisCon))
+}
+
+func TestRedisCacheWaitForConnectionTimeout(t *testing.T) {
+	c := New(RedisCache, ConnectionString("redis://127.0.0.1:1/0?max_retries=-1"), WaitForConnection{
+		Timeout: 50 * time.Millisecond,
+		Backoff: 10 * time.Millisecond,
+	})
+
+	err := c.Start(context.TODO())
+	qt.Assert(t, qt.IsNotNil(err))
+	qt.Check(t, qt.ErrorIs(err, context.DeadlineExceeded))
+}
+
+func TestRedisCacheHealthUnreachable(t *testing.T) {
+	c := New(RedisCache, ConnectionString("redis://127.0.0.1:1/0?max_retries=-1"))
+	err := c.Start(context.TODO())
+	qt.Assert(t, qt.IsNil(err))
+	defer c.Close()
+
+	h := c.Health(context.TODO())
+	qt.Check(t, qt.IsFalse(h.Healthy))
+	qt.Check(t, qt.Not(qt.Equals(h.Error, "")))
+	qt.Assert(t, qt.IsNotNil(h.Pool))
+}
@@ -0,0 +1,274 @@
+// Copyright 2022 Azugo. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxKeyLength is the default maximum length of the encoded key
+// before it is shortened by hashing.
+const DefaultMaxKeyLength = 200
+
+// KeySeparator is used to join parts of the composite keys.
+const KeySeparator = ":"
+
+// KeyEncoder encodes typed key to the cache key string.
+type KeyEncoder[K any] func(key K) string
+
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// StringKey returns key encoder for string keys.
+func StringKey[K ~string]() KeyEncoder[K] {
+	return func(key K) string {
+		return string(key)
+	}
+}
+
+// IntKey returns key encoder for integer keys.
+func IntKey[K integer]() KeyEncoder[K] {
+	return func(key K) string {
+		if key < 0 {
+			return strconv.FormatInt(int64(key), 10)
+		}
+
+		return strconv.FormatUint(uint64(key), 10)
+	}
+}
+
+// UUIDKey returns key encoder for UUID keys represented as 16 byte arrays
+// (e.g. github.com/google/uuid.UUID). Key is encoded in canonical
+// xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx form.
+func UUIDKey[K ~[16]byte]() KeyEncoder[K] {
+	return func(key K) string {
+		return encodeUUID(key)
+	}
+}
+
+// StringerKey returns key encoder for keys that implement fmt.Stringer interface.
+func StringerKey[K fmt.Stringer]() KeyEncoder[K] {
+	return func(key K) string {
+		return key.String()
+	}
+}
+
+// StructKey returns key encoder for composite struct keys.
+//
+// All exported struct fields are encoded in declaration order and joined
+// with the KeySeparator. Fields can be excluded using `cache:"-"` tag.
+// Supported field types are strings, integers, booleans, 16 byte arrays
+// (UUIDs), time.Time and types implementing fmt.Stringer.
+//
+// Panics if K is not a struct or contains fields of unsupported types.
+func StructKey[K any]() KeyEncoder[K] {
+	t := reflect.TypeFor[K]()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("cache: struct key type expected, got %s", t))
+	}
+
+	type field struct {
+		index  int
+		encode func(v reflect.Value) string
+	}
+
+	fields := make([]field, 0, t.NumField())
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() || f.Tag.Get("cache") == "-" {
+			continue
+		}
+
+		enc := keyPartEncoder(f.Type)
+		if enc == nil {
+			panic(fmt.Sprintf("cache: unsupported key field %s type %s", f.Name, f.Type))
+		}
+
+		fields = append(fields, field{index: i, encode: enc})
+	}
+
+	return func(key K) string {
+		v := reflect.ValueOf(key)
+
+		var b strings.Builder
+
+		for i, f := range fields {
+			if i > 0 {
+				b.WriteString(KeySeparator)
+			}
+
+			b.WriteString(f.encode(v.Field(f.index)))
+		}
+
+		return b.String()
+	}
+}
+
+var keyPartEscaper = strings.NewReplacer("%", "%25", KeySeparator, "%3A")
+
+var (
+	typeStringer = reflect.TypeFor[fmt.Stringer]()
+	typeTime     = reflect.TypeFor[time.Time]()
+)
+
+func keyPartEncoder(t reflect.Type) func(v reflect.Value) string {
+	switch {
+	case t == typeTime:
+		return func(v reflect.Value) string {
+			tm, _ := reflect.TypeAssert[time.Time](v)
+
+			return tm.UTC().Format(time.RFC3339Nano)
+		}
+	case t.Implements(typeStringer):
+		return func(v reflect.Value) string {
+			s, _ := reflect.TypeAssert[fmt.Stringer](v)
+
+			return keyPartEscaper.Replace(s.String())
+		}
+	}
+
+	//nolint:exhaustive // all other kinds are not supported
+	switch t.Kind() {
+	case reflect.String:
+		return func(v reflect.Value) string {
+			return keyPartEscaper.Replace(v.String())
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) string {
+			return strconv.FormatInt(v.Int(), 10)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(v reflect.Value) string {
+			return strconv.FormatUint(v.Uint(), 10)
+		}
+	case reflect.Bool:
+		return func(v reflect.Value) string {
+			return strconv.FormatBool(v.Bool())
+		}
+	case reflect.Array:
+		if t.Len() != 16 || t.Elem().Kind() != reflect.Uint8 {
+			return nil
+		}
+
+		return func(v reflect.Value) string {
+			var u [16]byte
+
+			reflect.Copy(reflect.ValueOf(&u).Elem(), v)
+
+			return encodeUUID(u)
+		}
+	default:
+		return nil
+	}
+}
+
+func encodeUUID[K ~[16]byte](u K) string {
+	var buf [36]byte
+
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+
+	return string(buf[:])
+}
+
+// HashLongKeys returns key encoder that shortens keys longer than maxLen.
+//
+// Long keys are truncated and suffixed with the SHA-256 hash of the full key
+// so that the resulting key is exactly maxLen long.
+func HashLongKeys[K any](encode KeyEncoder[K], maxLen int) KeyEncoder[K] {
+	return func(key K) string {
+		return hashLongKey(encode(key), maxLen)
+	}
+}
+
+func hashLongKey(key string, maxLen int) string {
+	if maxLen <= 0 || len(key) <= maxLen {
+		return key
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(sum[:])
+
+	if maxLen <= len(hash)+1 {
+		return hash[:maxLen]
+	}
+
+	return key[:maxLen-len(hash)-1] + "#" + hash
+}
+
+// Keyed is a cache instance wrapper that uses typed keys.
+type Keyed[K, T any] struct {
+	instance Instance[T]
+	encode   KeyEncoder[K]
+	maxLen   int
+}
+
+// NewKeyed returns a new cache instance wrapper that encodes typed keys
+// using the provided key encoder.
+//
+// Keys longer than DefaultMaxKeyLength are shortened by hashing.
+func NewKeyed[K, T any](instance Instance[T], encode KeyEncoder[K]) *Keyed[K, T] {
+	return &Keyed[K, T]{
+		instance: instance,
+		encode:   encode,
+		maxLen:   DefaultMaxKeyLength,
+	}
+}
+
+// WithMaxKeyLength returns a copy of the wrapper with different maximum key
+// length after which keys are shortened by hashing. Zero disables hashing.
+func (k *Keyed[K, T]) WithMaxKeyLength(maxLen int) *Keyed[K, T] {
+	return &Keyed[K, T]{
+		instance: k.instance,
+		encode:   k.encode,
+		maxLen:   maxLen,
+	}
+}
+
+// Instance returns the underlying cache instance.
+func (k *Keyed[K, T]) Instance() Instance[T] {
+	return k.instance
+}
+
+// Key returns the encoded cache key.
+func (k *Keyed[K, T]) Key(key K) string {
+	return hashLongKey(k.encode(key), k.maxLen)
+}
+
+// Get value from cache. If value is not found, it will return default value.
+func (k *Keyed[K, T]) Get(ctx context.Context, key K, opts ...ItemOption[T]) (T, error) {
+	return k.instance.Get(ctx, k.Key(key), opts...)
+}
+
+// Pop returns value from the cache and deletes it. If value is not found, it will return KeyNotFoundError error.
+func (k *Keyed[K, T]) Pop(ctx context.Context, key K) (T, error) {
+	return k.instance.Pop(ctx, k.Key(key))
+}
+
+// Set value in cache.
+func (k *Keyed[K, T]) Set(ctx context.Context, key K, value T, opts ...ItemOption[T]) error {
+	return k.instance.Set(ctx, k.Key(key), value, opts...)
+}
+
+// Delete value from cache.
+func (k *Keyed[K, T]) Delete(ctx context.Context, key K) error {
+	return k.instance.Delete(ctx, k.Key(key))
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-quicktest/qt"
+)
+
+type testUUID [16]byte
+
+type testTenantKey struct {
+	Tenant  string
+	ID      int64
+	Account testUUID
+	Active  bool
+	skipped string
+	Ignored string `cache:"-"`
+}
+
+func TestKeyEncoders(t *testing.T) {
+	qt.Check(t, qt.Equals(StringKey[string]()("key"), "key"))
+	qt.Check(t, qt.Equals(IntKey[int]()(-42), "-42"))
+	qt.Check(t, qt.Equals(IntKey[uint64]()(18446744073709551615), "18446744073709551615"))
+
+	u := testUUID{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	qt.Check(t, qt.Equals(UUIDKey[testUUID]()(u), "123e4567-e89b-12d3-a456-426614174000"))
+
+	enc := StructKey[testTenantKey]()
+	qt.Check(t, qt.Equals(enc(testTenantKey{
+		Tenant:  "acme:corp",
+		ID:      7,
+		Account: u,
+		Active:  true,
+		skipped: "a",
+		Ignored: "b",
+	}), "acme%3Acorp:7:123e4567-e89b-12d3-a456-426614174000:true"))
+}
+
+func TestStructKeyUnsupported(t *testing.T) {
+	qt.Check(t, qt.PanicMatches(func() {
+		StructKey[string]()
+	}, `cache: struct key type expected, got string`))
+
+	qt.Check(t, qt.PanicMatches(func() {
+		StructKey[struct{ Values []string }]()
+	}, `cache: unsupported key field Values type \[\]string`))
+}
+
+func TestHashLongKeys(t *testing.T) {
+	enc := HashLongKeys(StringKey[string](), 100)
+
+	qt.Check(t, qt.Equals(enc("short"), "short"))
+
+	long := strings.Repeat("a", 150)
+	key := enc(long)
+	qt.Check(t, qt.HasLen(key, 100))
+	qt.Check(t, qt.IsTrue(strings.HasPrefix(key, strings.Repeat("a", 35)+"#")))
+	qt.Check(t, qt.Not(qt.Equals(key, enc(strings.Repeat("a", 151)))))
+
+	qt.Check(t, qt.HasLen(HashLongKeys(StringKey[string](), 10)(long), 10))
+}
+
+func TestKeyedCache(t *testing.T) {
+	c := New(MemoryCache)
+	err := c.Start(context.TODO())
+	qt.Assert(t, qt.IsNil(err))
+	defer c.Close()
+
+	i, err := Create[string](c, "test")
+	qt.Assert(t, qt.IsNil(err))
+
+	k := NewKeyed(i, StructKey[testTenantKey]())
+
+	err = k.Set(context.TODO(), testTenantKey{Tenant: "acme", ID: 1}, "value")
+	qt.Check(t, qt.IsNil(err))
+
+	// Even if a Set gets applied, it might take a few milliseconds after the call has returned to the user.
+	// In database terms, it is an eventual consistency model.
+	time.Sleep(10 * time.Millisecond)
+
+	val, err := i.Get(context.TODO(), "acme:1:00000000-0000-0000-0000-000000000000:false")
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, "value"))
+
+	val, err = k.Pop(context.TODO(), testTenantKey{Tenant: "acme", ID: 1})
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, "value"))
+
+	qt.Check(t, qt.HasLen(k.Key(testTenantKey{Tenant: strings.Repeat("a", 300)}), DefaultMaxKeyLength))
+	qt.Check(t, qt.HasLen(k.WithMaxKeyLength(0).Key(testTenantKey{Tenant: strings.Repeat("a", 300)}), 345))
+}
@@ -0,0 +1,400 @@
+// Copyright 2022 Azugo. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"azugo.io/core/instrumenter"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/goccy/go-json"
+)
+
+const (
+	// memcachedMaxKeyLength is the maximum key length supported by memcached.
+	memcachedMaxKeyLength = 250
+	// memcachedMaxRelativeTTL is the maximum expiration time in seconds that
+	// memcached treats as relative. Larger values are treated as Unix time.
+	memcachedMaxRelativeTTL = 30 * 24 * 60 * 60
+	// memcachedPopAttempts is the number of times to retry pop on concurrent modification.
+	memcachedPopAttempts = 5
+)
+
+// MemcachedOptions contains Memcached client options.
+type MemcachedOptions struct {
+	// Servers is the list of Memcached server addresses.
+	Servers []string
+	// Timeout is the socket read/write timeout.
+	Timeout time.Duration
+	// MaxIdleConns is the maximum number of idle connections per server.
+	MaxIdleConns int
+}
+
+// ParseMemcachedURL parses Memcached URL to extract connection information.
+//
+// URL format is memcached://host1:11211,host2:11211?timeout=100ms&max_idle_conns=10.
+func ParseMemcachedURL(urlStr string) (*MemcachedOptions, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "memcached" {
+		return nil, errors.New("memcached URL must start with memcached:// scheme")
+	}
+
+	if u.Host == "" {
+		return nil, errors.New("memcached server addresses are required")
+	}
+
+	options := &MemcachedOptions{}
+
+	for addr := range strings.SplitSeq(u.Host, ",") {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("invalid memcached server address %q: %w", addr, err)
+		}
+
+		options.Servers = append(options.Servers, addr)
+	}
+
+	if u.RawQuery != "" {
+		q := u.Query()
+
+		if v := q.Get("timeout"); v != "" {
+			timeout, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout value: %w", err)
+			}
+
+			options.Timeout = timeout
+		}
+
+		if v := q.Get("max_idle_conns"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid max_idle_conns value: %w", err)
+			}
+
+			options.MaxIdleConns = n
+		}
+	}
+
+	return options, nil
+}
+
+func newMemcachedClient(constr string) (*memcache.Client, error) {
+	options, err := ParseMemcachedURL(constr)
+	if err != nil {
+		return nil, err
+	}
+
+	con := memcache.New(options.Servers...)
+	con.Timeout = options.Timeout
+	con.MaxIdleConns = options.MaxIdleConns
+
+	return con, nil
+}
+
+type memcachedCache[T any] struct {
+	con          *memcache.Client
+	prefix       string
+	ttl          time.Duration
+	loader       func(ctx context.Context, key string) (any, error)
+	instrumenter instrumenter.Instrumenter
+}
+
+func newMemcachedCache[T any](prefix string, con *memcache.Client, opts ...Option) Instance[T] {
+	opt := newCacheOptions(opts...)
+
+	keyPrefix := opt.KeyPrefix
+	if keyPrefix != "" {
+		keyPrefix += ":"
+	}
+
+	loader := opt.Loader
+	if loader != nil {
+		loader = func(ctx context.Context, key string) (any, error) {
+			finish := opt.Instrumenter.Observe(ctx, InstrumentationLoader, key)
+			v, err := opt.Loader(ctx, key)
+			finish(err)
+
+			return v, err
+		}
+	}
+
+	return &memcachedCache[T]{
+		con:          con,
+		prefix:       keyPrefix + prefix + ":",
+		ttl:          opt.TTL,
+		loader:       loader,
+		instrumenter: opt.Instrumenter,
+	}
+}
+
+func memcachedLegalKey(key string) bool {
+	if len(key) > memcachedMaxKeyLength {
+		return false
+	}
+
+	for i := range len(key) {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+
+	return true
+}
+
+// key returns the full Memcached key. Keys that are too long or contain
+// characters not allowed by Memcached are replaced by their hash.
+func (c *memcachedCache[T]) key(key string) string {
+	k := c.prefix + key
+	if memcachedLegalKey(k) {
+		return k
+	}
+
+	sum := sha256.Sum256([]byte(k))
+	hash := hex.EncodeToString(sum[:])
+
+	if memcachedLegalKey(c.prefix + hash) {
+		return c.prefix + hash
+	}
+
+	return hash
+}
+
+// expiration converts TTL to the Memcached expiration time.
+func memcachedExpiration(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+
+	secs := int64(math.Ceil(ttl.Seconds()))
+	if secs > memcachedMaxRelativeTTL {
+		secs = time.Now().Add(ttl).Unix()
+	}
+
+	if secs > math.MaxInt32 {
+		return math.MaxInt32
+	}
+
+	return int32(secs)
+}
+
+func (c *memcachedCache[T]) Get(ctx context.Context, key string, opts ...ItemOption[T]) (T, error) {
+	val := new(T)
+	if c.con == nil {
+		return *val, ErrCacheClosed
+	}
+
+	k := c.key(key)
+
+	finish := c.instrumenter.Observe(ctx, InstrumentationGet, k)
+
+	item, err := c.con.Get(k)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		if c.loader != nil {
+			v, err := c.loader(ctx, key)
+			if err != nil {
+				finish(err)
+
+				return *val, err
+			}
+
+			vv, ok := v.(T)
+			if !ok {
+				err = fmt.Errorf("invalid value from loader: %v", v)
+				finish(err)
+
+				return *val, err
+			}
+
+			if err := c.Set(ctx, key, vv, opts...); err != nil {
+				finish(err)
+
+				return *val, err
+			}
+
+			finish(nil)
+
+			return vv, nil
+		}
+
+		finish(nil)
+
+		return *val, nil
+	}
+
+	if err != nil {
+		finish(err)
+
+		return *val, err
+	}
+
+	if err := json.Unmarshal(item.Value, val); err != nil {
+		err = fmt.Errorf("invalid cache value: %w", err)
+		finish(err)
+
+		return *val, err
+	}
+
+	finish(nil)
+
+	return *val, nil
+}
+
+// Pop returns value from the cache and deletes it.
+//
+// As Memcached does not support atomic get and delete, value is read with
+// gets and then claimed by replacing it with an already expired item using
+// compare-and-swap, so that only one caller can pop the same value.
+func (c *memcachedCache[T]) Pop(ctx context.Context, key string) (T, error) {
+	val := new(T)
+	if c.con == nil {
+		return *val, ErrCacheClosed
+	}
+
+	k := c.key(key)
+
+	finishG := c.instrumenter.Observe(ctx, InstrumentationGet, k)
+	finishD := c.instrumenter.Observe(ctx, InstrumentationDelete, k)
+
+	finish := func(err error) {
+		finishD(err)
+		finishG(err)
+	}
+
+	for range memcachedPopAttempts {
+		item, err := c.con.Get(k)
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			finish(nil)
+
+			return *val, KeyNotFoundError{Key: key}
+		}
+
+		if err != nil {
+			finish(err)
+
+			return *val, err
+		}
+
+		value := item.Value
+
+		item.Value = nil
+		item.Expiration = -1
+
+		err = c.con.CompareAndSwap(item)
+		if errors.Is(err, memcache.ErrCASConflict) {
+			// Value was modified concurrently, try again.
+			continue
+		}
+
+		if errors.Is(err, memcache.ErrNotStored) || errors.Is(err, memcache.ErrCacheMiss) {
+			finish(nil)
+
+			return *val, KeyNotFoundError{Key: key}
+		}
+
+		if err != nil {
+			finish(err)
+
+			return *val, err
+		}
+
+		if err := json.Unmarshal(value, val); err != nil {
+			err = fmt.Errorf("invalid cache value: %w", err)
+			finish(err)
+
+			return *val, err
+		}
+
+		finish(nil)
+
+		return *val, nil
+	}
+
+	err := fmt.Errorf("failed to pop key '%s': %w", key, memcache.ErrCASConflict)
+	finish(err)
+
+	return *val, err
+}
+
+func (c *memcachedCache[T]) Set(ctx context.Context, key string, value T, opts ...ItemOption[T]) error {
+	if c.con == nil {
+		return ErrCacheClosed
+	}
+
+	k := c.key(key)
+
+	finish := c.instrumenter.Observe(ctx, InstrumentationSet, k)
+
+	buf, err := json.Marshal(value)
+	if err != nil {
+		err = fmt.Errorf("invalid cache value: %w", err)
+		finish(err)
+
+		return err
+	}
+
+	opt := newItemOptions(opts...)
+
+	ttl := c.ttl
+	if opt.TTL != 0 {
+		ttl = opt.TTL
+	}
+
+	if err := c.con.Set(&memcache.Item{
+		Key:        k,
+		Value:      buf,
+		Expiration: memcachedExpiration(ttl),
+	}); err != nil {
+		finish(err)
+
+		return err
+	}
+
+	finish(nil)
+
+	return nil
+}
+
+func (c *memcachedCache[T]) Delete(ctx context.Context, key string) error {
+	if c.con == nil {
+		return ErrCacheClosed
+	}
+
+	k := c.key(key)
+
+	finish := c.instrumenter.Observe(ctx, InstrumentationDelete, k)
+
+	if err := c.con.Delete(k); err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+		finish(err)
+
+		return err
+	}
+
+	finish(nil)
+
+	return nil
+}
+
+func (c *memcachedCache[T]) Ping(_ context.Context) error {
+	if c.con == nil {
+		return nil
+	}
+
+	return c.con.Ping()
+}
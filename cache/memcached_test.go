@@ -0,0 +1,295 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-quicktest/qt"
+)
+
+type testMemcachedItem struct {
+	value   []byte
+	flags   string
+	cas     uint64
+	expires time.Time
+}
+
+// testMemcachedServer is a minimal in-process Memcached text protocol server.
+type testMemcachedServer struct {
+	ln    net.Listener
+	lock  sync.Mutex
+	items map[string]*testMemcachedItem
+	cas   uint64
+}
+
+func newTestMemcachedServer(t *testing.T) *testMemcachedServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	qt.Assert(t, qt.IsNil(err))
+
+	s := &testMemcachedServer{
+		ln:    ln,
+		items: make(map[string]*testMemcachedItem),
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() { _ = ln.Close() })
+
+	return s
+}
+
+func (s *testMemcachedServer) ConnectionString() string {
+	return "memcached://" + s.ln.Addr().String()
+}
+
+func (s *testMemcachedServer) get(key string) *testMemcachedItem {
+	item, ok := s.items[key]
+	if !ok {
+		return nil
+	}
+
+	if !item.expires.IsZero() && !time.Now().Before(item.expires) {
+		delete(s.items, key)
+
+		return nil
+	}
+
+	return item
+}
+
+func (s *testMemcachedServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+
+		s.lock.Lock()
+
+		switch args[0] {
+		case "version":
+			fmt.Fprint(rw, "VERSION 1.6.0\r\n")
+		case "gets", "get":
+			for _, key := range args[1:] {
+				if item := s.get(key); item != nil {
+					fmt.Fprintf(rw, "VALUE %s %s %d %d\r\n%s\r\n", key, item.flags, len(item.value), item.cas, item.value)
+				}
+			}
+
+			fmt.Fprint(rw, "END\r\n")
+		case "set", "cas":
+			size, _ := strconv.Atoi(args[4])
+			value := make([]byte, size+2)
+
+			if _, err := io.ReadFull(rw, value); err != nil {
+				s.lock.Unlock()
+
+				return
+			}
+
+			item := s.get(args[1])
+			if args[0] == "cas" {
+				cas, _ := strconv.ParseUint(args[5], 10, 64)
+
+				if item == nil {
+					fmt.Fprint(rw, "NOT_FOUND\r\n")
+
+					break
+				}
+
+				if item.cas != cas {
+					fmt.Fprint(rw, "EXISTS\r\n")
+
+					break
+				}
+			}
+
+			s.cas++
+			item = &testMemcachedItem{
+				value: value[:size],
+				flags: args[2],
+				cas:   s.cas,
+			}
+
+			exp, _ := strconv.Atoi(args[3])
+			if exp < 0 {
+				item.expires = time.Now()
+			} else if exp > 0 {
+				item.expires = time.Now().Add(time.Duration(exp) * time.Second)
+			}
+
+			s.items[args[1]] = item
+
+			fmt.Fprint(rw, "STORED\r\n")
+		case "delete":
+			if s.get(args[1]) == nil {
+				fmt.Fprint(rw, "NOT_FOUND\r\n")
+
+				break
+			}
+
+			delete(s.items, args[1])
+
+			fmt.Fprint(rw, "DELETED\r\n")
+		default:
+			fmt.Fprint(rw, "ERROR\r\n")
+		}
+
+		s.lock.Unlock()
+
+		if err := rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func TestParseMemcachedURL(t *testing.T) {
+	o, err := ParseMemcachedURL("memcached://host1:11211,host2:11212?timeout=250ms&max_idle_conns=5")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.DeepEquals(o.Servers, []string{"host1:11211", "host2:11212"}))
+	qt.Check(t, qt.Equals(o.Timeout, 250*time.Millisecond))
+	qt.Check(t, qt.Equals(o.MaxIdleConns, 5))
+
+	qt.Check(t, qt.IsNotNil(ValidateConnectionString(MemcachedCache, "redis://host1:11211")))
+	qt.Check(t, qt.IsNotNil(ValidateConnectionString(MemcachedCache, "memcached://host1")))
+	qt.Check(t, qt.IsNil(ValidateConnectionString(MemcachedCache, "memcached://host1:11211")))
+}
+
+func TestMemcachedCacheGetSet(t *testing.T) {
+	s := newTestMemcachedServer(t)
+
+	c := New(MemcachedCache, KeyPrefix("prefix"), ConnectionString(s.ConnectionString()))
+	err := c.Start(context.TODO())
+	qt.Assert(t, qt.IsNil(err))
+	defer c.Close()
+
+	qt.Check(t, qt.IsNil(c.Ping(context.TODO())))
+
+	i, err := Create[string](c, "test")
+	qt.Assert(t, qt.IsNil(err))
+
+	err = i.Set(context.TODO(), "key1", "value")
+	qt.Check(t, qt.IsNil(err))
+
+	qt.Check(t, qt.IsNotNil(s.items["prefix:test:key1"]))
+
+	val, err := i.Get(context.TODO(), "key1")
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, "value"))
+
+	err = i.Delete(context.TODO(), "key1")
+	qt.Check(t, qt.IsNil(err))
+
+	val, err = i.Get(context.TODO(), "key1")
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, ""))
+}
+
+func TestMemcachedCachePop(t *testing.T) {
+	s := newTestMemcachedServer(t)
+
+	c := New(MemcachedCache, ConnectionString(s.ConnectionString()))
+	err := c.Start(context.TODO())
+	qt.Assert(t, qt.IsNil(err))
+	defer c.Close()
+
+	i, err := Create[string](c, "test")
+	qt.Assert(t, qt.IsNil(err))
+
+	err = i.Set(context.TODO(), "key2", "value")
+	qt.Check(t, qt.IsNil(err))
+
+	val, err := i.Pop(context.TODO(), "key2")
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, "value"))
+
+	val, err = i.Pop(context.TODO(), "key2")
+	qt.Check(t, qt.ErrorAs(err, new(KeyNotFoundError)))
+	qt.Check(t, qt.Equals(val, ""))
+}
+
+func TestMemcachedCacheExpire(t *testing.T) {
+	s := newTestMemcachedServer(t)
+
+	c := New(MemcachedCache, ConnectionString(s.ConnectionString()))
+	err := c.Start(context.TODO())
+	qt.Assert(t, qt.IsNil(err))
+	defer c.Close()
+
+	i, err := Create[string](c, "test", DefaultTTL(time.Second))
+	qt.Assert(t, qt.IsNil(err))
+
+	err = i.Set(context.TODO(), "key3", "value")
+	qt.Check(t, qt.IsNil(err))
+
+	val, err := i.Get(context.TODO(), "key3")
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, "value"))
+
+	time.Sleep(1100 * time.Millisecond)
+
+	val, err = i.Get(context.TODO(), "key3")
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, ""))
+}
+
+func TestMemcachedCacheLongKey(t *testing.T) {
+	s := newTestMemcachedServer(t)
+
+	c := New(MemcachedCache, ConnectionString(s.ConnectionString()))
+	err := c.Start(context.TODO())
+	qt.Assert(t, qt.IsNil(err))
+	defer c.Close()
+
+	i, err := Create[string](c, "test", Loader(func(_ context.Context, key string) (any, error) {
+		return "loaded", nil
+	}))
+	qt.Assert(t, qt.IsNil(err))
+
+	key := "key with spaces " + strings.Repeat("a", 300)
+
+	val, err := i.Get(context.TODO(), key)
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, "loaded"))
+
+	qt.Check(t, qt.HasLen(s.items, 1))
+
+	for k := range s.items {
+		qt.Check(t, qt.IsTrue(memcachedLegalKey(k)))
+	}
+}
+
+func TestMemcachedCacheExpiration(t *testing.T) {
+	qt.Check(t, qt.Equals(memcachedExpiration(0), int32(0)))
+	qt.Check(t, qt.Equals(memcachedExpiration(100*time.Millisecond), int32(1)))
+	qt.Check(t, qt.Equals(memcachedExpiration(time.Hour), int32(3600)))
+	qt.Check(t, qt.IsTrue(int64(memcachedExpiration(60*24*time.Hour)) > time.Now().Unix()))
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -108,3 +109,36 @@ func TestMemoryCacheItemExpire(t *testing.T) {
 	qt.Check(t, qt.IsNil(err))
 	qt.Check(t, qt.Equals(val, ""))
 }
+
+func TestGetOrCreate(t *testing.T) {
+	c := New(MemoryCache)
+	qt.Assert(t, qt.IsNil(c.Start(context.TODO())))
+
+	instances := make(chan Instance[string], 10)
+
+	var wg sync.WaitGroup
+	for range cap(instances) {
+		wg.Go(func() {
+			i, err := GetOrCreate[string](c, "test")
+			qt.Check(t, qt.IsNil(err))
+
+			instances <- i
+		})
+	}
+
+	wg.Wait()
+	close(instances)
+
+	first := <-instances
+	for i := range instances {
+		qt.Check(t, qt.Equals(i, first))
+	}
+
+	_, err := GetOrCreate[int](c, "test")
+	qt.Check(t, qt.ErrorMatches(err, "invalid cache type"))
+
+	c.Close()
+
+	_, err = GetOrCreate[string](c, "test")
+	qt.Check(t, qt.ErrorIs(err, ErrCacheClosed))
+}
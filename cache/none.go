@@ -0,0 +1,95 @@
+// Copyright 2022 Azugo. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"azugo.io/core/instrumenter"
+)
+
+// noneCache is a cache that does not store anything.
+//
+// Every Get is a cache miss that calls the loader if one is configured.
+type noneCache[T any] struct {
+	loader       func(ctx context.Context, key string) (any, error)
+	instrumenter instrumenter.Instrumenter
+}
+
+func newNoneCache[T any](opts ...Option) Instance[T] {
+	opt := newCacheOptions(opts...)
+
+	c := &noneCache[T]{
+		instrumenter: opt.Instrumenter,
+	}
+
+	if opt.Loader != nil {
+		loader := opt.Loader
+		c.loader = func(ctx context.Context, key string) (any, error) {
+			finish := opt.Instrumenter.Observe(ctx, InstrumentationLoader, key)
+			v, err := loader(ctx, key)
+			finish(err)
+
+			return v, err
+		}
+	}
+
+	return c
+}
+
+func (c *noneCache[T]) Get(ctx context.Context, key string, _ ...ItemOption[T]) (T, error) {
+	finish := c.instrumenter.Observe(ctx, InstrumentationGet, key)
+
+	var val T
+
+	if c.loader == nil {
+		finish(nil)
+
+		return val, nil
+	}
+
+	raw, err := c.loader(ctx, key)
+	if err != nil {
+		finish(err)
+
+		return val, err
+	}
+
+	v, ok := raw.(T)
+	if !ok {
+		err = fmt.Errorf("invalid value from loader: %v", raw)
+		finish(err)
+
+		return val, err
+	}
+
+	finish(nil)
+
+	return v, nil
+}
+
+func (c *noneCache[T]) Pop(ctx context.Context, key string) (T, error) {
+	finish := c.instrumenter.Observe(ctx, InstrumentationGet, key)
+	defer finish(nil)
+
+	var val T
+
+	return val, KeyNotFoundError{Key: key}
+}
+
+func (c *noneCache[T]) Set(ctx context.Context, key string, _ T, _ ...ItemOption[T]) error {
+	finish := c.instrumenter.Observe(ctx, InstrumentationSet, key)
+	defer finish(nil)
+
+	return nil
+}
+
+func (c *noneCache[T]) Delete(ctx context.Context, key string) error {
+	finish := c.instrumenter.Observe(ctx, InstrumentationDelete, key)
+	defer finish(nil)
+
+	return nil
+}
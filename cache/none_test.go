@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-quicktest/qt"
+)
+
+func TestNoneCache(t *testing.T) {
+	c := New(NoneCache)
+	err := c.Start(context.TODO())
+	qt.Assert(t, qt.IsNil(err))
+	defer c.Close()
+
+	i, err := Create[string](c, "test")
+	qt.Assert(t, qt.IsNil(err))
+
+	err = i.Set(context.TODO(), "key", "value")
+	qt.Check(t, qt.IsNil(err))
+
+	val, err := i.Get(context.TODO(), "key")
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, ""))
+
+	_, err = i.Pop(context.TODO(), "key")
+	qt.Check(t, qt.ErrorAs(err, new(KeyNotFoundError)))
+
+	qt.Check(t, qt.IsNil(i.Delete(context.TODO(), "key")))
+}
+
+func TestNoneCacheLoader(t *testing.T) {
+	c := New(NoneCache)
+	err := c.Start(context.TODO())
+	qt.Assert(t, qt.IsNil(err))
+	defer c.Close()
+
+	calls := 0
+	i, err := Create[string](c, "test", Loader(func(_ context.Context, key string) (any, error) {
+		calls++
+		return "loaded " + key, nil
+	}))
+	qt.Assert(t, qt.IsNil(err))
+
+	for range 2 {
+		val, err := i.Get(context.TODO(), "key")
+		qt.Check(t, qt.IsNil(err))
+		qt.Check(t, qt.Equals(val, "loaded key"))
+	}
+
+	qt.Check(t, qt.Equals(calls, 2))
+}
@@ -23,6 +23,19 @@ type cacheOptions struct {
 	Instrumenter       instrumenter.Instrumenter
 	Serialize          bool
 	Logger             *zap.Logger
+	ReadFromReplicas   bool
+	RouteByLatency     bool
+	RouteRandomly      bool
+	WaitForConnection  *WaitForConnection
+}
+
+// redisRouting returns read routing settings for the Redis backends.
+func (o *cacheOptions) redisRouting() redisRouting {
+	return redisRouting{
+		ReadOnly:       o.ReadFromReplicas || o.RouteByLatency || o.RouteRandomly,
+		RouteByLatency: o.RouteByLatency,
+		RouteRandomly:  o.RouteRandomly,
+	}
 }
 
 // Option for the cache instance.
@@ -72,6 +85,11 @@ const (
 	RedisClusterCache Type = "redis-cluster"
 	// RedisSentinelCache store data in Redis database with sentinel.
 	RedisSentinelCache Type = "redis-sentinel"
+	// MemcachedCache store data in Memcached servers.
+	MemcachedCache Type = "memcached"
+	// NoneCache does not store any data. All reads are cache misses that
+	// still call the Loader if one is configured.
+	NoneCache Type = "none"
 )
 
 func (t Type) applyCache(c *cacheOptions) {
@@ -152,3 +170,51 @@ type Serialize bool
 func (s Serialize) applyCache(c *cacheOptions) {
 	c.Serialize = bool(s)
 }
+
+// ReadFromReplicas routes cache reads (Get) to the Redis replica nodes while
+// writes (Set, Delete and Pop) are still sent to the primary.
+//
+// For the plain Redis cache replicas must be listed in the connection string
+// using the replicas query parameter. For Redis Cluster it enables read-only
+// mode and for Redis Sentinel reads are routed to replicas discovered by
+// sentinel. Has no effect on the memory cache.
+type ReadFromReplicas bool
+
+func (r ReadFromReplicas) applyCache(c *cacheOptions) {
+	c.ReadFromReplicas = bool(r)
+}
+
+// RouteByLatency routes cache reads to the Redis node with the lowest latency.
+// Enables ReadFromReplicas.
+type RouteByLatency bool
+
+func (r RouteByLatency) applyCache(c *cacheOptions) {
+	c.RouteByLatency = bool(r)
+}
+
+// RouteRandomly routes cache reads to a random Redis node. Enables ReadFromReplicas.
+type RouteRandomly bool
+
+func (r RouteRandomly) applyCache(c *cacheOptions) {
+	c.RouteRandomly = bool(r)
+}
+
+// WaitForConnection makes cache Start to wait until the cache backend is
+// reachable. Connection is retried with exponential backoff until it succeeds,
+// maximum number of attempts is reached, timeout expires or context is canceled.
+//
+// Has no effect on the memory cache.
+type WaitForConnection struct {
+	// Timeout is the maximum time to wait for the connection. Zero means no timeout.
+	Timeout time.Duration
+	// MaxAttempts is the maximum number of connection attempts. Zero means unlimited.
+	MaxAttempts int
+	// Backoff is the initial delay between connection attempts (defaults to 500ms).
+	Backoff time.Duration
+	// MaxBackoff is the maximum delay between connection attempts (defaults to 10s).
+	MaxBackoff time.Duration
+}
+
+func (w WaitForConnection) applyCache(c *cacheOptions) {
+	c.WaitForConnection = &w
+}
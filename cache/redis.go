@@ -9,6 +9,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"reflect"
 	"strconv"
@@ -37,13 +38,15 @@ func setRedisLogger(logger *zap.Logger) {
 
 type redisCache[T any] struct {
 	con          redis.Cmdable
+	replicas     *redisReplicas
+	routing      redisRouting
 	prefix       string
 	ttl          time.Duration
 	loader       func(ctx context.Context, key string) (any, error)
 	instrumenter instrumenter.Instrumenter
 }
 
-func newRedisCache[T any](prefix string, con redis.Cmdable, opts ...Option) Instance[T] {
+func newRedisCache[T any](prefix string, con redis.Cmdable, replicas *redisReplicas, opts ...Option) Instance[T] {
 	opt := newCacheOptions(opts...)
 
 	keyPrefix := opt.KeyPrefix
@@ -64,6 +67,8 @@ func newRedisCache[T any](prefix string, con redis.Cmdable, opts ...Option) Inst
 
 	return &redisCache[T]{
 		con:          con,
+		replicas:     replicas,
+		routing:      opt.redisRouting(),
 		prefix:       keyPrefix + prefix + ":",
 		ttl:          opt.TTL,
 		loader:       loader,
@@ -71,68 +76,138 @@ func newRedisCache[T any](prefix string, con redis.Cmdable, opts ...Option) Inst
 	}
 }
 
-func parseCustomURLAttr(v string) (string, bool, error) {
+// customURLAttrs are connection string attributes that are not supported by the Redis client itself.
+type customURLAttrs struct {
+	InsecureSkipVerify bool
+	Replicas           []string
+}
+
+func parseCustomURLAttr(v string) (string, customURLAttrs, error) {
+	var attrs customURLAttrs
+
 	u, err := url.Parse(v)
 	if err != nil {
-		return "", false, err
+		return "", attrs, err
 	}
 
-	var insecureSkipVerify bool
-
 	if u.RawQuery != "" {
-		if q := u.Query(); q.Get("skip_verify") == "true" {
-			insecureSkipVerify = true
+		q := u.Query()
+
+		if q.Get("skip_verify") == "true" {
+			attrs.InsecureSkipVerify = true
 
 			q.Del("skip_verify")
-			u.RawQuery = q.Encode()
 		}
+
+		if q.Has("replicas") {
+			for _, r := range q["replicas"] {
+				for addr := range strings.SplitSeq(r, ",") {
+					if addr = strings.TrimSpace(addr); addr != "" {
+						attrs.Replicas = append(attrs.Replicas, addr)
+					}
+				}
+			}
+
+			q.Del("replicas")
+		}
+
+		u.RawQuery = q.Encode()
 	}
 
-	return u.String(), insecureSkipVerify, nil
+	return u.String(), attrs, nil
 }
 
 // ParseRedisClusterURL parses a Redis Cluster URL string into cluster client options.
 func ParseRedisClusterURL(v string) (*redis.ClusterOptions, error) {
-	v, insecureSkipVerify, err := parseCustomURLAttr(v)
+	v, attrs, err := parseCustomURLAttr(v)
 	if err != nil {
 		return nil, err
 	}
 
 	o, err := redis.ParseClusterURL(v)
-	if err == nil && insecureSkipVerify {
+	if err == nil && attrs.InsecureSkipVerify {
 		if o.TLSConfig == nil {
 			o.TLSConfig = &tls.Config{
 				MinVersion: tls.VersionTLS12,
 			}
 		}
 
-		o.TLSConfig.InsecureSkipVerify = insecureSkipVerify
+		o.TLSConfig.InsecureSkipVerify = true
 	}
 
 	return o, err
 }
 
 // ParseRedisURL parses a Redis URL string into client options.
+//
+// Replica addresses listed in the replicas query parameter are ignored,
+// use ParseRedisReplicaURLs to get client options for them.
 func ParseRedisURL(v string) (*redis.Options, error) {
-	v, insecureSkipVerify, err := parseCustomURLAttr(v)
+	v, attrs, err := parseCustomURLAttr(v)
 	if err != nil {
 		return nil, err
 	}
 
+	return parseRedisURL(v, attrs)
+}
+
+func parseRedisURL(v string, attrs customURLAttrs) (*redis.Options, error) {
 	o, err := redis.ParseURL(v)
-	if err == nil && insecureSkipVerify {
+	if err == nil && attrs.InsecureSkipVerify {
 		if o.TLSConfig == nil {
 			o.TLSConfig = &tls.Config{
 				MinVersion: tls.VersionTLS12,
 			}
 		}
 
-		o.TLSConfig.InsecureSkipVerify = insecureSkipVerify
+		o.TLSConfig.InsecureSkipVerify = true
 	}
 
 	return o, err
 }
 
+// ParseRedisReplicaURLs parses replica addresses from the Redis URL string
+// into client options for each of the replicas.
+//
+// Replicas are specified as a comma separated list of addresses in the
+// replicas query parameter (e.g. redis://primary:6379/0?replicas=replica1:6379,replica2:6379).
+// All other connection settings are shared with the primary.
+func ParseRedisReplicaURLs(v string) ([]*redis.Options, error) {
+	v, attrs, err := parseCustomURLAttr(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(attrs.Replicas) == 0 {
+		return nil, nil
+	}
+
+	u, err := url.Parse(v)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := make([]*redis.Options, 0, len(attrs.Replicas))
+
+	for _, addr := range attrs.Replicas {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("invalid replica address %q: %w", addr, err)
+		}
+
+		ru := *u
+		ru.Host = addr
+
+		o, err := parseRedisURL(ru.String(), attrs)
+		if err != nil {
+			return nil, err
+		}
+
+		replicas = append(replicas, o)
+	}
+
+	return replicas, nil
+}
+
 func newRedisClient(constr, password string) (redis.Cmdable, error) {
 	redisOptions, err := ParseRedisURL(constr)
 	if err != nil {
@@ -147,7 +222,27 @@ func newRedisClient(constr, password string) (redis.Cmdable, error) {
 	return redis.NewClient(redisOptions), nil
 }
 
-func newRedisClusterClient(constr, password string) (redis.Cmdable, error) {
+func newRedisReplicaClients(constr, password string) (*redisReplicas, error) {
+	replicaOptions, err := ParseRedisReplicaURLs(constr)
+	if err != nil || len(replicaOptions) == 0 {
+		return nil, err
+	}
+
+	clients := make([]*redis.Client, 0, len(replicaOptions))
+
+	for _, o := range replicaOptions {
+		// If password is provided override provided in connection string.
+		if len(password) != 0 {
+			o.Password = password
+		}
+
+		clients = append(clients, redis.NewClient(o))
+	}
+
+	return newRedisReplicas(clients), nil
+}
+
+func newRedisClusterClient(constr, password string, routing redisRouting) (redis.Cmdable, error) {
 	redisOptions, err := ParseRedisClusterURL(constr)
 	if err != nil {
 		return nil, err
@@ -158,11 +253,18 @@ func newRedisClusterClient(constr, password string) (redis.Cmdable, error) {
 		redisOptions.Password = password
 	}
 
+	redisOptions.ReadOnly = redisOptions.ReadOnly || routing.ReadOnly
+	redisOptions.RouteByLatency = redisOptions.RouteByLatency || routing.RouteByLatency
+	redisOptions.RouteRandomly = redisOptions.RouteRandomly || routing.RouteRandomly
+
 	return redis.NewClusterClient(redisOptions), nil
 }
 
 // newRedisSentinelClient creates a new Redis Sentinel client.
-func newRedisSentinelClient(connectionString, password string) (redis.Cmdable, error) {
+//
+// When reads are routed to replicas a cluster client is created that sends
+// read-only commands to replicas and all other commands to the master.
+func newRedisSentinelClient(connectionString, password string, routing redisRouting) (redis.Cmdable, error) {
 	options, err := ParseRedisSentinelURL(connectionString)
 	if err != nil {
 		return nil, err
@@ -173,12 +275,26 @@ func newRedisSentinelClient(connectionString, password string) (redis.Cmdable, e
 		options.Password = password
 	}
 
+	options.RouteByLatency = options.RouteByLatency || routing.RouteByLatency
+	options.RouteRandomly = options.RouteRandomly || routing.RouteRandomly
+
+	if routing.ReadOnly && !options.RouteByLatency && !options.RouteRandomly {
+		options.RouteRandomly = true
+	}
+
+	if options.RouteByLatency || options.RouteRandomly {
+		return redis.NewFailoverClusterClient(options), nil
+	}
+
 	return redis.NewFailoverClient(options), nil
 }
 
 // ParseRedisSentinelURL parses Redis Sentinel URL to extract connection information.
+//
+// Supported query parameters are db, skip_verify, route_by_latency and route_randomly.
+// The read_only parameter is an alias for route_randomly.
 func ParseRedisSentinelURL(urlStr string) (*redis.FailoverOptions, error) {
-	urlStr, insecureSkipVerify, err := parseCustomURLAttr(urlStr)
+	urlStr, attrs, err := parseCustomURLAttr(urlStr)
 	if err != nil {
 		return nil, err
 	}
@@ -231,7 +347,28 @@ func ParseRedisSentinelURL(urlStr string) (*redis.FailoverOptions, error) {
 			options.DB = db
 		}
 
-		if insecureSkipVerify {
+		for _, name := range []string{"read_only", "route_by_latency", "route_randomly"} {
+			if !q.Has(name) {
+				continue
+			}
+
+			v, err := strconv.ParseBool(q.Get(name))
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s value: %w", name, err)
+			}
+
+			if !v {
+				continue
+			}
+
+			if name == "route_by_latency" {
+				options.RouteByLatency = true
+			} else {
+				options.RouteRandomly = true
+			}
+		}
+
+		if attrs.InsecureSkipVerify {
 			if options.TLSConfig == nil {
 				options.TLSConfig = &tls.Config{
 					MinVersion: tls.VersionTLS12,
@@ -252,7 +389,7 @@ func (c *redisCache[T]) Get(ctx context.Context, key string, opts ...ItemOption[
 	}
 
 	finish := c.instrumenter.Observe(ctx, InstrumentationGet, c.prefix+key)
-	s := c.con.Get(ctx, c.prefix+key)
+	s := c.get(ctx, c.prefix+key)
 
 	if errors.Is(s.Err(), redis.Nil) {
 		if c.loader != nil {
@@ -301,6 +438,34 @@ func (c *redisCache[T]) Get(ctx context.Context, key string, opts ...ItemOption[
 	return *val, nil
 }
 
+// get reads value from a replica if reads are routed to replicas, otherwise from the primary.
+//
+// In case replica is not reachable the value is read from the primary and
+// the replica is not used for reads for a while.
+func (c *redisCache[T]) get(ctx context.Context, key string) *redis.StringCmd {
+	if !c.routing.ReadOnly {
+		return c.con.Get(ctx, key)
+	}
+
+	idx := c.replicas.pick(c.routing)
+	if idx < 0 {
+		return c.con.Get(ctx, key)
+	}
+
+	start := time.Now()
+	s := c.replicas.clients[idx].Get(ctx, key)
+
+	if err := s.Err(); err != nil && !errors.Is(err, redis.Nil) {
+		c.replicas.fail(idx, time.Since(start))
+
+		return c.con.Get(ctx, key)
+	}
+
+	c.replicas.observe(idx, time.Since(start))
+
+	return s
+}
+
 func (c *redisCache[T]) Pop(ctx context.Context, key string) (T, error) {
 	val := new(T)
 	if c.con == nil {
@@ -426,5 +591,5 @@ func (c *redisCache[T]) Close() error {
 
 	c.con = nil
 
-	return err
+	return errors.Join(err, c.replicas.Close())
 }
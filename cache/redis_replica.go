@@ -0,0 +1,128 @@
+// Copyright 2022 Azugo. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisRouting contains settings on how to route read operations.
+type redisRouting struct {
+	ReadOnly       bool
+	RouteByLatency bool
+	RouteRandomly  bool
+}
+
+// replicaEjectTimeout is the time the replica is not used for reads after a failed read.
+const replicaEjectTimeout = 30 * time.Second
+
+// redisReplicas is a set of Redis replica clients used to serve reads for the plain Redis cache.
+//
+// Clients are never removed from the set so that they can be safely accessed
+// by index returned from pick while replicas are being closed.
+type redisReplicas struct {
+	clients []*redis.Client
+	closed  atomic.Bool
+	// latency contains moving average of the read latency for each of the replicas in nanoseconds.
+	latency []atomic.Int64
+	// ejected contains Unix time in nanoseconds until which the replica is not used after a failed read.
+	ejected []atomic.Int64
+	next    atomic.Uint32
+}
+
+func newRedisReplicas(clients []*redis.Client) *redisReplicas {
+	return &redisReplicas{
+		clients: clients,
+		latency: make([]atomic.Int64, len(clients)),
+		ejected: make([]atomic.Int64, len(clients)),
+	}
+}
+
+// pick returns the index of the replica that should serve the next read
+// or -1 if there are no replicas available or replicas are closed.
+func (r *redisReplicas) pick(routing redisRouting) int {
+	if r == nil || len(r.clients) == 0 || r.closed.Load() {
+		return -1
+	}
+
+	now := time.Now().UnixNano()
+
+	var start int
+
+	switch {
+	case routing.RouteByLatency:
+		idx, best := -1, int64(0)
+
+		for i := range r.latency {
+			if r.ejected[i].Load() > now {
+				continue
+			}
+
+			if l := r.latency[i].Load(); idx < 0 || l < best {
+				idx, best = i, l
+			}
+		}
+
+		return idx
+	case routing.RouteRandomly:
+		start = rand.IntN(len(r.clients)) //nolint:gosec
+	default:
+		start = int(r.next.Add(1)-1) % len(r.clients)
+	}
+
+	// Skip ejected replicas.
+	for i := range len(r.clients) {
+		idx := (start + i) % len(r.clients)
+		if r.ejected[idx].Load() <= now {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// observe records read latency for the replica.
+func (r *redisReplicas) observe(idx int, d time.Duration) {
+	l := &r.latency[idx]
+
+	prev := l.Load()
+	if prev == 0 {
+		l.Store(int64(d))
+
+		return
+	}
+
+	// Exponentially weighted moving average with smoothing factor 0.2.
+	l.Store(prev + (int64(d)-prev)/5)
+}
+
+// fail records failed read from the replica and ejects it for a while so that
+// reads are served by other replicas or the primary.
+func (r *redisReplicas) fail(idx int, d time.Duration) {
+	r.observe(idx, d)
+	r.ejected[idx].Store(time.Now().Add(replicaEjectTimeout).UnixNano())
+}
+
+// Close all replica connections.
+func (r *redisReplicas) Close() error {
+	if r == nil || !r.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	var errs []error
+
+	for _, c := range r.clients {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,27 @@
+package cert
+
+import (
+	"crypto/x509"
+	"errors"
+	"os"
+)
+
+// LoadCertPool creates a certificate pool from PEM encoded certificates bundle.
+func LoadCertPool(bundle []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, errors.New("no valid certificates found")
+	}
+
+	return pool, nil
+}
+
+// LoadCertPoolFromFile creates a certificate pool from PEM encoded certificates bundle file.
+func LoadCertPoolFromFile(path string) (*x509.CertPool, error) {
+	bundle, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadCertPool(bundle)
+}
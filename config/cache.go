@@ -14,13 +14,28 @@ import (
 	"github.com/spf13/viper"
 )
 
+// CacheStartup is the cache backend startup connection check configuration.
+type CacheStartup struct {
+	// Wait until cache backend is reachable on startup.
+	Wait bool `mapstructure:"wait"`
+	// Timeout is the maximum time to wait for the connection (defaults to no timeout).
+	Timeout time.Duration `mapstructure:"timeout" validate:"omitempty,min=0"`
+	// MaxAttempts is the maximum number of connection attempts (defaults to unlimited).
+	MaxAttempts int `mapstructure:"max_attempts" validate:"omitempty,min=0"`
+	// Backoff is the initial delay between connection attempts.
+	Backoff time.Duration `mapstructure:"backoff" validate:"omitempty,min=0"`
+	// MaxBackoff is the maximum delay between connection attempts.
+	MaxBackoff time.Duration `mapstructure:"max_backoff" validate:"omitempty,min=0"`
+}
+
 // Cache is the cache configuration section.
 type Cache struct {
-	Type             cache.Type    `mapstructure:"type" validate:"required,oneof=memory redis redis-cluster redis-sentinel"`
+	Type             cache.Type    `mapstructure:"type" validate:"required,oneof=memory redis redis-cluster redis-sentinel memcached none"`
 	TTL              time.Duration `mapstructure:"ttl" validate:"omitempty,min=0"`
 	ConnectionString string        `mapstructure:"connection" validate:"omitempty"`
 	Password         string        `mapstructure:"password" validate:"omitempty"`
 	KeyPrefix        string        `mapstructure:"key_prefix" validate:"omitempty"`
+	Startup          CacheStartup  `mapstructure:"startup"`
 }
 
 // Validate cache configuration section.
@@ -48,4 +63,10 @@ func (c *Cache) Bind(prefix string, v *viper.Viper) {
 	_ = v.BindEnv(prefix+".password", "CACHE_PASSWORD")
 	_ = v.BindEnv(prefix+".connection", "CACHE_CONNECTION")
 	_ = v.BindEnv(prefix+".key_prefix", "CACHE_KEY_PREFIX")
+
+	_ = v.BindEnv(prefix+".startup.wait", "CACHE_STARTUP_WAIT")
+	_ = v.BindEnv(prefix+".startup.timeout", "CACHE_STARTUP_TIMEOUT")
+	_ = v.BindEnv(prefix+".startup.max_attempts", "CACHE_STARTUP_MAX_ATTEMPTS")
+	_ = v.BindEnv(prefix+".startup.backoff", "CACHE_STARTUP_BACKOFF")
+	_ = v.BindEnv(prefix+".startup.max_backoff", "CACHE_STARTUP_MAX_BACKOFF")
 }
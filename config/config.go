@@ -30,6 +30,8 @@ type Configuration struct {
 
 	// Cache configuration section.
 	Cache *Cache
+	// HTTP client configuration section.
+	HTTP *HTTP
 	// Log configuration section.
 	Log *Log
 }
@@ -99,6 +101,7 @@ func Bind[T any](c *T, prefix string, v *viper.Viper) *T {
 // Bind configuration section to the configuration backend/instance.
 func (c *Configuration) Bind(_ string, v *viper.Viper) {
 	c.Cache = Bind(c.Cache, "cache", v)
+	c.HTTP = Bind(c.HTTP, "http", v)
 	c.Log = Bind(c.Log, "log", v)
 }
 
@@ -207,6 +210,10 @@ func (c *Configuration) Validate(validate *validation.Validate) error {
 		return err
 	}
 
+	if err := c.HTTP.Validate(validate); err != nil {
+		return err
+	}
+
 	if err := c.Log.Validate(validate); err != nil {
 		return err
 	}
@@ -0,0 +1,146 @@
+// Copyright 2022 Azugo. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"os"
+	"strings"
+
+	"azugo.io/core/http"
+
+	"github.com/spf13/viper"
+)
+
+// HTTP is the HTTP client configuration section.
+type HTTP struct {
+	http.Configuration `mapstructure:",squash"`
+}
+
+// Bind HTTP client configuration section.
+//
+// Named clients can be configured using environment variables by listing
+// client names in HTTP_CLIENTS environment variable separated by comma.
+// Client settings are then read from HTTP_CLIENT_<NAME>_<SETTING> variables
+// (e.g. HTTP_CLIENT_PAYMENTS_BASE_URL).
+func (c *HTTP) Bind(prefix string, v *viper.Viper) {
+	for name := range strings.SplitSeq(os.Getenv("HTTP_CLIENTS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		bindNamedHTTPClient(prefix+".clients."+name, "HTTP_CLIENT_"+envName(name)+"_", v)
+	}
+}
+
+func envName(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+func bindNamedHTTPClient(prefix, env string, v *viper.Viper) {
+	authPsw, _ := LoadRemoteSecret(env + "AUTH_PASSWORD")
+	authToken, _ := LoadRemoteSecret(env + "AUTH_TOKEN")
+	tlsPsw, _ := LoadRemoteSecret(env + "TLS_PASSWORD")
+	oauth2Secret, _ := LoadRemoteSecret(env + "AUTH_OAUTH2_CLIENT_SECRET")
+	hmacSecret, _ := LoadRemoteSecret(env + "SIGNING_HMAC_SECRET")
+	awsSecret, _ := LoadRemoteSecret(env + "SIGNING_AWS_SECRET_ACCESS_KEY")
+	awsToken, _ := LoadRemoteSecret(env + "SIGNING_AWS_SESSION_TOKEN")
+
+	v.SetDefault(prefix+".auth.password", authPsw)
+	v.SetDefault(prefix+".auth.token", authToken)
+	v.SetDefault(prefix+".tls.password", tlsPsw)
+	v.SetDefault(prefix+".auth.oauth2.client_secret", oauth2Secret)
+	v.SetDefault(prefix+".signing.hmac.secret", hmacSecret)
+	v.SetDefault(prefix+".signing.aws.secret_access_key", awsSecret)
+	v.SetDefault(prefix+".signing.aws.session_token", awsToken)
+
+	_ = v.BindEnv(prefix+".base_url", env+"BASE_URL")
+	_ = v.BindEnv(prefix+".base_urls", env+"BASE_URLS")
+	_ = v.BindEnv(prefix+".user_agent", env+"USER_AGENT")
+	_ = v.BindEnv(prefix+".timeout", env+"TIMEOUT")
+	_ = v.BindEnv(prefix+".dial_timeout", env+"DIAL_TIMEOUT")
+	_ = v.BindEnv(prefix+".read_timeout", env+"READ_TIMEOUT")
+	_ = v.BindEnv(prefix+".write_timeout", env+"WRITE_TIMEOUT")
+	_ = v.BindEnv(prefix+".idle_timeout", env+"IDLE_TIMEOUT")
+	_ = v.BindEnv(prefix+".proxy", env+"PROXY")
+	_ = v.BindEnv(prefix+".no_proxy", env+"NO_PROXY")
+	_ = v.BindEnv(prefix+".proxy_from_env", env+"PROXY_FROM_ENV")
+
+	_ = v.BindEnv(prefix+".tls.ca", env+"TLS_CA")
+	_ = v.BindEnv(prefix+".tls.cert", env+"TLS_CERT")
+	_ = v.BindEnv(prefix+".tls.key", env+"TLS_KEY")
+	_ = v.BindEnv(prefix+".tls.password", env+"TLS_PASSWORD")
+	_ = v.BindEnv(prefix+".tls.server_name", env+"TLS_SERVER_NAME")
+	_ = v.BindEnv(prefix+".tls.skip_verify", env+"TLS_SKIP_VERIFY")
+	_ = v.BindEnv(prefix+".tls.reload_interval", env+"TLS_RELOAD_INTERVAL")
+
+	_ = v.BindEnv(prefix+".auth.type", env+"AUTH_TYPE")
+	_ = v.BindEnv(prefix+".auth.username", env+"AUTH_USERNAME")
+	_ = v.BindEnv(prefix+".auth.password", env+"AUTH_PASSWORD")
+	_ = v.BindEnv(prefix+".auth.token", env+"AUTH_TOKEN")
+	_ = v.BindEnv(prefix+".auth.oauth2.token_url", env+"AUTH_OAUTH2_TOKEN_URL")
+	_ = v.BindEnv(prefix+".auth.oauth2.client_id", env+"AUTH_OAUTH2_CLIENT_ID")
+	_ = v.BindEnv(prefix+".auth.oauth2.client_secret", env+"AUTH_OAUTH2_CLIENT_SECRET")
+	_ = v.BindEnv(prefix+".auth.oauth2.scopes", env+"AUTH_OAUTH2_SCOPES")
+	_ = v.BindEnv(prefix+".auth.oauth2.audience", env+"AUTH_OAUTH2_AUDIENCE")
+	_ = v.BindEnv(prefix+".auth.oauth2.cert", env+"AUTH_OAUTH2_CERT")
+	_ = v.BindEnv(prefix+".auth.oauth2.password", env+"AUTH_OAUTH2_PASSWORD")
+	_ = v.BindEnv(prefix+".auth.oauth2.key_id", env+"AUTH_OAUTH2_KEY_ID")
+	_ = v.BindEnv(prefix+".auth.oauth2.tls.ca", env+"AUTH_OAUTH2_TLS_CA")
+	_ = v.BindEnv(prefix+".auth.oauth2.tls.cert", env+"AUTH_OAUTH2_TLS_CERT")
+	_ = v.BindEnv(prefix+".auth.oauth2.tls.key", env+"AUTH_OAUTH2_TLS_KEY")
+	_ = v.BindEnv(prefix+".auth.oauth2.tls.password", env+"AUTH_OAUTH2_TLS_PASSWORD")
+	_ = v.BindEnv(prefix+".auth.oauth2.tls.server_name", env+"AUTH_OAUTH2_TLS_SERVER_NAME")
+	_ = v.BindEnv(prefix+".auth.oauth2.tls.skip_verify", env+"AUTH_OAUTH2_TLS_SKIP_VERIFY")
+
+	_ = v.BindEnv(prefix+".signing.type", env+"SIGNING_TYPE")
+	_ = v.BindEnv(prefix+".signing.hmac.key_id", env+"SIGNING_HMAC_KEY_ID")
+	_ = v.BindEnv(prefix+".signing.hmac.secret", env+"SIGNING_HMAC_SECRET")
+	_ = v.BindEnv(prefix+".signing.hmac.algorithm", env+"SIGNING_HMAC_ALGORITHM")
+	_ = v.BindEnv(prefix+".signing.hmac.encoding", env+"SIGNING_HMAC_ENCODING")
+	_ = v.BindEnv(prefix+".signing.hmac.components", env+"SIGNING_HMAC_COMPONENTS")
+	_ = v.BindEnv(prefix+".signing.hmac.separator", env+"SIGNING_HMAC_SEPARATOR")
+	_ = v.BindEnv(prefix+".signing.hmac.header", env+"SIGNING_HMAC_HEADER")
+	_ = v.BindEnv(prefix+".signing.hmac.timestamp_header", env+"SIGNING_HMAC_TIMESTAMP_HEADER")
+	_ = v.BindEnv(prefix+".signing.hmac.format", env+"SIGNING_HMAC_FORMAT")
+	_ = v.BindEnv(prefix+".signing.aws.access_key_id", env+"SIGNING_AWS_ACCESS_KEY_ID")
+	_ = v.BindEnv(prefix+".signing.aws.secret_access_key", env+"SIGNING_AWS_SECRET_ACCESS_KEY")
+	_ = v.BindEnv(prefix+".signing.aws.session_token", env+"SIGNING_AWS_SESSION_TOKEN")
+	_ = v.BindEnv(prefix+".signing.aws.region", env+"SIGNING_AWS_REGION")
+	_ = v.BindEnv(prefix+".signing.aws.service", env+"SIGNING_AWS_SERVICE")
+	_ = v.BindEnv(prefix+".signing.aws.unsigned_payload", env+"SIGNING_AWS_UNSIGNED_PAYLOAD")
+
+	_ = v.BindEnv(prefix+".retry.max_attempts", env+"RETRY_MAX_ATTEMPTS")
+	_ = v.BindEnv(prefix+".retry.backoff", env+"RETRY_BACKOFF")
+	_ = v.BindEnv(prefix+".retry.max_backoff", env+"RETRY_MAX_BACKOFF")
+
+	_ = v.BindEnv(prefix+".rate_limit.rate", env+"RATE_LIMIT_RATE")
+	_ = v.BindEnv(prefix+".rate_limit.burst", env+"RATE_LIMIT_BURST")
+	_ = v.BindEnv(prefix+".rate_limit.fail_fast", env+"RATE_LIMIT_FAIL_FAST")
+
+	_ = v.BindEnv(prefix+".bulkhead.max_concurrent", env+"BULKHEAD_MAX_CONCURRENT")
+	_ = v.BindEnv(prefix+".bulkhead.fail_fast", env+"BULKHEAD_FAIL_FAST")
+
+	_ = v.BindEnv(prefix+".load_balancing.strategy", env+"LOAD_BALANCING_STRATEGY")
+	_ = v.BindEnv(prefix+".load_balancing.resolve", env+"LOAD_BALANCING_RESOLVE")
+	_ = v.BindEnv(prefix+".load_balancing.srv", env+"LOAD_BALANCING_SRV")
+	_ = v.BindEnv(prefix+".load_balancing.refresh_interval", env+"LOAD_BALANCING_REFRESH_INTERVAL")
+	_ = v.BindEnv(prefix+".load_balancing.failure_threshold", env+"LOAD_BALANCING_FAILURE_THRESHOLD")
+	_ = v.BindEnv(prefix+".load_balancing.eject_timeout", env+"LOAD_BALANCING_EJECT_TIMEOUT")
+	_ = v.BindEnv(prefix+".load_balancing.no_failover", env+"LOAD_BALANCING_NO_FAILOVER")
+
+	_ = v.BindEnv(prefix+".cache.enabled", env+"CACHE_ENABLED")
+	_ = v.BindEnv(prefix+".cache.private", env+"CACHE_PRIVATE")
+	_ = v.BindEnv(prefix+".cache.stale_if_error", env+"CACHE_STALE_IF_ERROR")
+	_ = v.BindEnv(prefix+".cache.keep_stale", env+"CACHE_KEEP_STALE")
+	_ = v.BindEnv(prefix+".cache.max_body_size", env+"CACHE_MAX_BODY_SIZE")
+
+	_ = v.BindEnv(prefix+".logging.enabled", env+"LOGGING_ENABLED")
+	_ = v.BindEnv(prefix+".logging.bodies", env+"LOGGING_BODIES")
+	_ = v.BindEnv(prefix+".logging.max_body_size", env+"LOGGING_MAX_BODY_SIZE")
+	_ = v.BindEnv(prefix+".logging.redact_headers", env+"LOGGING_REDACT_HEADERS")
+	_ = v.BindEnv(prefix+".logging.redact_query", env+"LOGGING_REDACT_QUERY")
+	_ = v.BindEnv(prefix+".logging.redact_fields", env+"LOGGING_REDACT_FIELDS")
+}
@@ -0,0 +1,260 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// abortTransport closes the connection of the request as soon as its context
+// is canceled so that aborted request does not keep waiting for the response.
+// Connection of the streamed response is closed if the context is canceled
+// before the body stream is closed.
+//
+// Custom transport owns its connections, so requests sent with it can not be
+// aborted and wait until the deadline of the request instead.
+type abortTransport struct {
+	// next is the transport used for other requests and for all requests
+	// if custom transport is configured.
+	next fasthttp.RoundTripper
+
+	requests sync.Map
+}
+
+type abortRequest struct {
+	ctx      context.Context
+	deadline time.Time
+}
+
+func newAbortTransport(next fasthttp.RoundTripper) *abortTransport {
+	return &abortTransport{next: next}
+}
+
+// register makes the request abortable when the context is canceled.
+// Returned function must be called after the request is completed.
+func (t *abortTransport) register(ctx context.Context, req *fasthttp.Request, deadline time.Time) func() {
+	t.requests.Store(req, &abortRequest{ctx: ctx, deadline: deadline})
+
+	return func() {
+		t.requests.Delete(req)
+	}
+}
+
+func (t *abortTransport) RoundTrip(hc *fasthttp.HostClient, req *fasthttp.Request, resp *fasthttp.Response) (bool, error) {
+	v, ok := t.requests.Load(req)
+	if !ok || t.next != nil {
+		next := t.next
+		if next == nil {
+			next = fasthttp.DefaultTransport
+		}
+
+		return next.RoundTrip(hc, req, resp)
+	}
+
+	ar, _ := v.(*abortRequest)
+
+	if err := ar.ctx.Err(); err != nil {
+		return false, err
+	}
+
+	var timeout time.Duration
+	if !ar.deadline.IsZero() {
+		timeout = time.Until(ar.deadline)
+	}
+
+	cc, err := hc.AcquireConn(timeout, req.ConnectionClose())
+	if err != nil {
+		return false, err
+	}
+
+	conn := cc.Conn()
+	stop := context.AfterFunc(ar.ctx, func() {
+		_ = conn.Close()
+	})
+
+	// fail closes the connection and returns context error if the request was aborted.
+	fail := func(err error, retry bool) (bool, error) {
+		stop()
+		hc.CloseConn(cc)
+
+		if cerr := ar.ctx.Err(); cerr != nil {
+			return false, cerr
+		}
+
+		if x, ok := err.(interface{ Timeout() bool }); ok && x.Timeout() {
+			err = fasthttp.ErrTimeout
+		}
+
+		return retry, err
+	}
+
+	// release returns the connection to the pool if it can be reused and was not
+	// closed by the canceled context.
+	release := func(reuse bool) {
+		if stop() && reuse {
+			hc.ReleaseConn(cc)
+		} else {
+			hc.CloseConn(cc)
+		}
+	}
+
+	resp.ParseNetConn(conn)
+
+	if err = conn.SetWriteDeadline(connDeadline(ar.deadline, hc.WriteTimeout)); err != nil {
+		return fail(err, true)
+	}
+
+	bw := hc.AcquireWriter(conn)
+
+	err = req.Write(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+
+	hc.ReleaseWriter(bw)
+
+	if err != nil {
+		return fail(err, true)
+	}
+
+	if err = conn.SetReadDeadline(connDeadline(ar.deadline, hc.ReadTimeout)); err != nil {
+		return fail(err, true)
+	}
+
+	if req.Header.IsHead() {
+		resp.SkipBody = true
+	}
+
+	if hc.DisableHeaderNamesNormalizing {
+		resp.Header.DisableNormalizing()
+	}
+
+	if resp.StreamBody {
+		return t.stream(ar.ctx, hc, req, resp, conn, fail, release)
+	}
+
+	br := hc.AcquireReader(conn)
+	err = resp.ReadLimitBody(br, hc.MaxResponseBodySize)
+	hc.ReleaseReader(br)
+
+	if err != nil {
+		return fail(err, err != fasthttp.ErrBodyTooLarge) //nolint:errorlint
+	}
+
+	if ar.ctx.Err() != nil {
+		// Connection is already closed by the canceled context.
+		return fail(nil, false)
+	}
+
+	release(!req.ConnectionClose() && !resp.ConnectionClose())
+
+	return false, nil
+}
+
+// stream reads the response headers and sets the response body stream that
+// keeps the connection until it is closed. Connection is closed if the context
+// is canceled while the body is read.
+func (t *abortTransport) stream(
+	ctx context.Context,
+	hc *fasthttp.HostClient,
+	req *fasthttp.Request,
+	resp *fasthttp.Response,
+	conn net.Conn,
+	fail func(err error, retry bool) (bool, error),
+	release func(reuse bool),
+) (bool, error) {
+	// Response body stream can not be replaced without closing it, so the
+	// response is read into the separate response that is kept until the
+	// body stream is closed.
+	body := fasthttp.AcquireResponse()
+	body.StreamBody = true
+	body.SkipBody = resp.SkipBody
+
+	if hc.DisableHeaderNamesNormalizing {
+		body.Header.DisableNormalizing()
+	}
+
+	br := hc.AcquireReader(conn)
+
+	if err := body.ReadLimitBody(br, hc.MaxResponseBodySize); err != nil {
+		hc.ReleaseReader(br)
+		fasthttp.ReleaseResponse(body)
+
+		return fail(err, err != fasthttp.ErrBodyTooLarge) //nolint:errorlint
+	}
+
+	reuse := !req.ConnectionClose() && !body.Header.ConnectionClose()
+
+	body.Header.CopyTo(&resp.Header)
+
+	stream := body.BodyStream()
+	if stream == nil {
+		hc.ReleaseReader(br)
+		fasthttp.ReleaseResponse(body)
+		release(reuse)
+
+		return false, nil
+	}
+
+	// Body that fits into the prefetch buffer is already read.
+	_, eof := stream.(*bytes.Reader)
+
+	resp.SetBodyStream(&abortBody{
+		ctx: ctx,
+		r:   stream,
+		eof: eof,
+		close: func(eof bool) {
+			fasthttp.ReleaseResponse(body)
+			hc.ReleaseReader(br)
+			release(reuse && eof)
+		},
+	}, body.Header.ContentLength())
+
+	return false, nil
+}
+
+// abortBody is the response body stream that releases the connection when closed.
+type abortBody struct {
+	ctx   context.Context
+	r     io.Reader
+	eof   bool
+	close func(eof bool)
+}
+
+func (b *abortBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+
+	switch {
+	case err == io.EOF: //nolint:errorlint
+		b.eof = true
+	case err != nil && b.ctx.Err() != nil:
+		err = context.Cause(b.ctx)
+	}
+
+	return n, err
+}
+
+func (b *abortBody) Close() error {
+	if b.close != nil {
+		b.close(b.eof)
+		b.close = nil
+	}
+
+	return nil
+}
+
+// connDeadline returns the earlier of the request deadline and the connection timeout.
+func connDeadline(deadline time.Time, timeout time.Duration) time.Time {
+	if timeout > 0 {
+		if d := time.Now().Add(timeout); deadline.IsZero() || d.Before(deadline) {
+			return d
+		}
+	}
+
+	return deadline
+}
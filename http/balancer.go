@@ -0,0 +1,552 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBalancerRefreshInterval  = 30 * time.Second
+	defaultBalancerFailureThreshold = 3
+	defaultBalancerEjectTimeout     = 30 * time.Second
+)
+
+// BalanceStrategy defines how the endpoint is selected for the request.
+type BalanceStrategy int
+
+const (
+	// BalanceRoundRobin selects endpoints in turn.
+	BalanceRoundRobin BalanceStrategy = iota
+	// BalanceRandom selects random endpoint.
+	BalanceRandom
+	// BalanceLeastInFlight selects endpoint with the least number of requests in flight.
+	BalanceLeastInFlight
+)
+
+// String returns the name of the balance strategy.
+func (s BalanceStrategy) String() string {
+	switch s {
+	case BalanceRoundRobin:
+		return "round_robin"
+	case BalanceRandom:
+		return "random"
+	case BalanceLeastInFlight:
+		return "least_in_flight"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// ParseBalanceStrategy parses the balance strategy name.
+func ParseBalanceStrategy(s string) (BalanceStrategy, error) {
+	switch strings.ToLower(s) {
+	case "", "round_robin":
+		return BalanceRoundRobin, nil
+	case "random":
+		return BalanceRandom, nil
+	case "least_in_flight":
+		return BalanceLeastInFlight, nil
+	default:
+		return 0, fmt.Errorf("unsupported balance strategy %q", s)
+	}
+}
+
+// LoadBalancer distributes client requests between multiple endpoints.
+//
+// Requests with URL starting with the client base URL are sent to one of the
+// endpoints by replacing the base URL part. If client base URL is not set, the
+// first URL is used as the base URL.
+//
+// Endpoints that fail FailureThreshold consecutive times are ejected for
+// EjectTimeout. Idempotent requests that fail with connection error are sent
+// again to another endpoint.
+type LoadBalancer struct {
+	// URLs is the list of endpoint base URLs (defaults to client base URL).
+	URLs []string
+	// Resolve enables periodic DNS resolution of the endpoint hostnames. Every resolved
+	// address becomes a separate endpoint and hostname is sent in the Host header and
+	// used as TLS server name for HTTPS endpoints.
+	Resolve bool
+	// SRV is the DNS SRV record name (e.g. _http._tcp.api.service.local) to discover
+	// endpoints. Discovered endpoints replace the host of the base URL.
+	SRV string
+	// RefreshInterval is the interval between DNS lookups (defaults to 30s).
+	RefreshInterval time.Duration
+	// Strategy is the endpoint selection strategy (defaults to round robin).
+	Strategy BalanceStrategy
+	// FailureThreshold is the number of consecutive failures that ejects the endpoint (defaults to 3).
+	FailureThreshold int
+	// EjectTimeout is the time the endpoint stays ejected (defaults to 30s).
+	EjectTimeout time.Duration
+	// NoFailover disables sending failed idempotent requests to another endpoint.
+	NoFailover bool
+	// Resolver is the DNS resolver to use (defaults to net.DefaultResolver).
+	Resolver *net.Resolver
+}
+
+func (b LoadBalancer) apply(o *options) {
+	o.LoadBalancer = &b
+	o.balancer = nil
+}
+
+// NoEndpointsError is returned when load balancer does not have any endpoints to send request to.
+type NoEndpointsError struct {
+	// BaseURL is the client base URL.
+	BaseURL string
+}
+
+func (e NoEndpointsError) Error() string {
+	return fmt.Sprintf("no endpoints available for %q", e.BaseURL)
+}
+
+type endpoint struct {
+	url        string
+	addr       string
+	host       string
+	serverName string
+	inFlight   atomic.Int64
+
+	failures     int
+	ejectedUntil time.Time
+}
+
+// balancer holds endpoints state shared between clients derived from the same client.
+type balancer struct {
+	config *LoadBalancer
+	base   string
+	next   atomic.Uint64
+	now    func() time.Time
+
+	lock      sync.RWMutex
+	endpoints []*endpoint
+	resolved  time.Time
+	initial   sync.Once
+	resolving atomic.Bool
+}
+
+func newBalancer(config *LoadBalancer, base string) *balancer {
+	c := *config
+
+	if len(c.URLs) == 0 && base != "" {
+		c.URLs = []string{base}
+	}
+
+	if c.RefreshInterval <= 0 {
+		c.RefreshInterval = defaultBalancerRefreshInterval
+	}
+
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = defaultBalancerFailureThreshold
+	}
+
+	if c.EjectTimeout <= 0 {
+		c.EjectTimeout = defaultBalancerEjectTimeout
+	}
+
+	if c.Resolver == nil {
+		c.Resolver = net.DefaultResolver
+	}
+
+	b := &balancer{
+		config: &c,
+		base:   strings.TrimSuffix(base, "/"),
+		now:    time.Now,
+	}
+
+	if !b.discovery() {
+		b.endpoints = staticEndpoints(c.URLs)
+	}
+
+	return b
+}
+
+func staticEndpoints(urls []string) []*endpoint {
+	endpoints := make([]*endpoint, 0, len(urls))
+	for _, u := range urls {
+		endpoints = append(endpoints, &endpoint{url: strings.TrimSuffix(u, "/")})
+	}
+
+	return endpoints
+}
+
+// discovery reports if endpoints are discovered using DNS.
+func (b *balancer) discovery() bool {
+	return b.config.Resolve || b.config.SRV != ""
+}
+
+// path returns the request URL part after the base URL or false if request is not sent to the base URL.
+func (b *balancer) path(u string) (string, bool) {
+	if b.base == "" {
+		return "", false
+	}
+
+	rest, ok := strings.CutPrefix(u, b.base)
+	if !ok || (rest != "" && rest[0] != '/' && rest[0] != '?') {
+		return "", false
+	}
+
+	return rest, true
+}
+
+// refresh resolves endpoints if they are stale. Initial resolution blocks,
+// following ones are done in the background.
+func (b *balancer) refresh(ctx context.Context, logger *zap.Logger) {
+	if !b.discovery() {
+		return
+	}
+
+	b.lock.RLock()
+	resolved := b.resolved
+	b.lock.RUnlock()
+
+	if !resolved.IsZero() && b.now().Sub(resolved) < b.config.RefreshInterval {
+		return
+	}
+
+	if resolved.IsZero() {
+		// Concurrent requests wait for the initial resolution to complete.
+		b.initial.Do(func() {
+			b.resolve(ctx, logger)
+		})
+
+		return
+	}
+
+	if !b.resolving.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer b.resolving.Store(false)
+
+		b.resolve(context.WithoutCancel(ctx), logger)
+	}()
+}
+
+func (b *balancer) resolve(ctx context.Context, logger *zap.Logger) {
+	var (
+		found []*endpoint
+		err   error
+	)
+
+	if b.config.SRV != "" {
+		found, err = b.lookupSRV(ctx)
+	} else {
+		found, err = b.lookupHosts(ctx)
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.resolved = b.now()
+
+	if err != nil || len(found) == 0 {
+		logger.Warn("HTTP client endpoint discovery failed", zap.String("base_url", b.base), zap.Error(err))
+
+		if len(b.endpoints) == 0 && b.config.SRV == "" {
+			b.endpoints = staticEndpoints(b.config.URLs)
+		}
+
+		return
+	}
+
+	// Keep health state of the endpoints that are still present.
+	current := make(map[string]*endpoint, len(b.endpoints))
+	for _, ep := range b.endpoints {
+		current[ep.url] = ep
+	}
+
+	for i, ep := range found {
+		if old, ok := current[ep.url]; ok {
+			found[i] = old
+		}
+	}
+
+	b.endpoints = found
+}
+
+func (b *balancer) lookupSRV(ctx context.Context) ([]*endpoint, error) {
+	u, err := url.Parse(b.base)
+	if err != nil {
+		return nil, err
+	}
+
+	_, addrs, err := b.config.Resolver.LookupSRV(ctx, "", "", b.config.SRV)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]*endpoint, 0, len(addrs))
+
+	for _, addr := range addrs {
+		// Records with higher priority value are only used as backup.
+		if addr.Priority != addrs[0].Priority {
+			break
+		}
+
+		u.Host = net.JoinHostPort(strings.TrimSuffix(addr.Target, "."), fmt.Sprint(addr.Port))
+		endpoints = append(endpoints, &endpoint{url: strings.TrimSuffix(u.String(), "/")})
+	}
+
+	return endpoints, nil
+}
+
+func (b *balancer) lookupHosts(ctx context.Context) ([]*endpoint, error) {
+	endpoints := make([]*endpoint, 0, len(b.config.URLs))
+
+	for _, raw := range b.config.URLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+
+		ips, err := b.config.Resolver.LookupHost(ctx, u.Hostname())
+		if err != nil {
+			return nil, err
+		}
+
+		host, serverName := u.Host, u.Hostname()
+
+		for _, ip := range ips {
+			u.Host = net.JoinHostPort(ip, port)
+			endpoints = append(endpoints, &endpoint{
+				url:        strings.TrimSuffix(u.String(), "/"),
+				addr:       u.Host,
+				host:       host,
+				serverName: serverName,
+			})
+		}
+	}
+
+	return endpoints, nil
+}
+
+// tlsConfig returns TLS configuration for the host client with server name set
+// to the hostname of the resolved endpoint address.
+func (b *balancer) tlsConfig(conf *tls.Config, addr string) *tls.Config {
+	if conf != nil && conf.ServerName != "" {
+		return conf
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	for _, ep := range b.endpoints {
+		if ep.addr != addr || ep.serverName == "" {
+			continue
+		}
+
+		if conf == nil {
+			conf = &tls.Config{MinVersion: tls.VersionTLS12}
+		} else {
+			conf = conf.Clone()
+		}
+
+		conf.ServerName = ep.serverName
+
+		return conf
+	}
+
+	return conf
+}
+
+// pick selects the endpoint for the request skipping already tried endpoints.
+// Ejected endpoints are only used if there are no healthy endpoints left.
+func (b *balancer) pick(tried []*endpoint) *endpoint {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	now := b.now()
+
+	var healthy, ejected []*endpoint
+
+	for _, ep := range b.endpoints {
+		if containsEndpoint(tried, ep) {
+			continue
+		}
+
+		if now.Before(ep.ejectedUntil) {
+			ejected = append(ejected, ep)
+		} else {
+			healthy = append(healthy, ep)
+		}
+	}
+
+	if len(healthy) == 0 {
+		healthy = ejected
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	n := b.next.Add(1) - 1
+
+	switch b.config.Strategy {
+	case BalanceRandom:
+		return healthy[rand.IntN(len(healthy))] //nolint:gosec
+	case BalanceLeastInFlight:
+		best := healthy[n%uint64(len(healthy))]
+		for _, ep := range healthy {
+			if ep.inFlight.Load() < best.inFlight.Load() {
+				best = ep
+			}
+		}
+
+		return best
+	default:
+		return healthy[n%uint64(len(healthy))]
+	}
+}
+
+func containsEndpoint(list []*endpoint, ep *endpoint) bool {
+	for _, e := range list {
+		if e == ep {
+			return true
+		}
+	}
+
+	return false
+}
+
+// done records the request result and reports if endpoint has been ejected.
+func (b *balancer) done(ep *endpoint, failure bool) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !failure {
+		ep.failures = 0
+
+		return false
+	}
+
+	ep.failures++
+	if ep.failures < b.config.FailureThreshold {
+		return false
+	}
+
+	ep.failures = 0
+	ep.ejectedUntil = b.now().Add(b.config.EjectTimeout)
+
+	return true
+}
+
+// isLocalError reports if error has been returned by the client itself without contacting the endpoint.
+func isLocalError(err error) bool {
+	var (
+		rateErr     RateLimitExceededError
+		bulkheadErr BulkheadFullError
+	)
+
+	return errors.Is(err, context.Canceled) || errors.As(err, &rateErr) || errors.As(err, &bulkheadErr)
+}
+
+// balance sends the request attempt to one of the load balancer endpoints
+// failing over to other endpoints on connection errors.
+func (c client) balance(ctx context.Context, req *Request, resp *Response, attempt int) error {
+	if c.balancer == nil {
+		if err := c.sign(ctx, req); err != nil {
+			return err
+		}
+
+		return c.attempt(ctx, req, resp, attempt)
+	}
+
+	uri := req.URI().String()
+
+	path, ok := c.balancer.path(uri)
+	if !ok {
+		if err := c.sign(ctx, req); err != nil {
+			return err
+		}
+
+		return c.attempt(ctx, req, resp, attempt)
+	}
+
+	c.balancer.refresh(ctx, c.Logger)
+
+	useHostHeader := req.UseHostHeader
+	failover := !c.balancer.config.NoFailover && isIdempotent(req) && !req.IsBodyStream()
+
+	defer func() {
+		req.SetRequestURI(uri)
+		req.UseHostHeader = useHostHeader
+	}()
+
+	var (
+		tried   []*endpoint
+		lastErr error = NoEndpointsError{BaseURL: c.balancer.base}
+	)
+
+	for {
+		ep := c.balancer.pick(tried)
+		if ep == nil {
+			return lastErr
+		}
+
+		if len(tried) > 0 {
+			resetResponse(resp)
+		}
+
+		req.SetRequestURI(ep.url + path)
+
+		if ep.host != "" && !useHostHeader {
+			req.UseHostHeader = true
+			req.Header.SetHost(ep.host)
+		}
+
+		if err := c.sign(ctx, req); err != nil {
+			return err
+		}
+
+		ep.inFlight.Add(1)
+		err := c.attempt(ctx, req, resp, attempt)
+
+		if err == nil && resp.IsBodyStream() {
+			// Request is in flight until the body stream is closed or released.
+			release := resp.release
+			resp.release = func() {
+				ep.inFlight.Add(-1)
+
+				if release != nil {
+					release()
+				}
+			}
+		} else {
+			ep.inFlight.Add(-1)
+		}
+
+		var circuitErr CircuitOpenError
+
+		if !isLocalError(err) && !errors.As(err, &circuitErr) &&
+			c.balancer.done(ep, defaultCircuitIsFailure(resp, err)) {
+			c.Logger.Warn("HTTP client endpoint ejected", zap.String("endpoint", ep.url))
+		}
+
+		if err == nil || !failover || ctx.Err() != nil || isLocalError(err) {
+			return err
+		}
+
+		tried = append(tried, ep)
+		lastErr = err
+	}
+}
@@ -0,0 +1,409 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"azugo.io/core/cert"
+
+	"github.com/go-quicktest/qt"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func newBalancerTestServer(t *testing.T, down ...string) (*[]string, *atomic.Int32, Option) {
+	t.Helper()
+
+	var (
+		lock  sync.Mutex
+		hosts []string
+		dials atomic.Int32
+	)
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		hosts = append(hosts, string(ctx.Host())+string(ctx.RequestURI()))
+	}
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	dial := s.DialContext()
+
+	return &hosts, &dials, DialContextFunc(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		for _, d := range down {
+			if addr == d {
+				dials.Add(1)
+
+				return nil, errors.New("connection refused")
+			}
+		}
+
+		return dial(ctx, network, addr)
+	})
+}
+
+// newTestResolver returns DNS resolver that resolves the hostnames to the IPv4 addresses.
+func newTestResolver(hosts map[string][]string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(context.Context, string, string) (net.Conn, error) {
+			client, server := net.Pipe()
+
+			go serveTestDNS(server, hosts)
+
+			return client, nil
+		},
+	}
+}
+
+func serveTestDNS(conn net.Conn, hosts map[string][]string) {
+	defer conn.Close()
+
+	for {
+		var size uint16
+		if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+			return
+		}
+
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+
+		var p dnsmessage.Parser
+
+		h, err := p.Start(buf)
+		if err != nil {
+			return
+		}
+
+		q, err := p.Question()
+		if err != nil {
+			return
+		}
+
+		ips, ok := hosts[q.Name.String()]
+
+		rcode := dnsmessage.RCodeSuccess
+		if !ok {
+			rcode = dnsmessage.RCodeNameError
+		}
+
+		b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, Authoritative: true, RCode: rcode})
+		b.EnableCompression()
+		_ = b.StartQuestions()
+		_ = b.Question(q)
+		_ = b.StartAnswers()
+
+		if q.Type == dnsmessage.TypeA {
+			for _, ip := range ips {
+				_ = b.AResource(
+					dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60},
+					dnsmessage.AResource{A: netip.MustParseAddr(ip).As4()},
+				)
+			}
+		}
+
+		msg, err := b.Finish()
+		if err != nil {
+			return
+		}
+
+		if err := binary.Write(conn, binary.BigEndian, uint16(len(msg))); err != nil { //nolint:gosec
+			return
+		}
+
+		if _, err := conn.Write(msg); err != nil {
+			return
+		}
+	}
+}
+
+func TestLoadBalancerRoundRobin(t *testing.T) {
+	hosts, _, dial := newBalancerTestServer(t)
+
+	c := NewClient(dial, LoadBalancer{URLs: []string{"http://a/api", "http://b/api/"}})
+	qt.Check(t, qt.Equals(c.BaseURL(), "http://a/api"))
+
+	for range 4 {
+		_, err := c.Get("/items", WithQueryArg("page", "1"))
+		qt.Assert(t, qt.IsNil(err))
+	}
+
+	qt.Check(t, qt.DeepEquals(*hosts, []string{
+		"a/api/items?page=1",
+		"b/api/items?page=1",
+		"a/api/items?page=1",
+		"b/api/items?page=1",
+	}))
+
+	// Requests to other hosts are not balanced.
+	_, err := c.Get("http://c/items")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals((*hosts)[4], "c/items"))
+}
+
+func TestLoadBalancerFailover(t *testing.T) {
+	hosts, dials, dial := newBalancerTestServer(t, "down:80")
+
+	c := NewClient(dial, LoadBalancer{
+		URLs:             []string{"http://down", "http://up"},
+		FailureThreshold: 1,
+	})
+
+	for range 3 {
+		_, err := c.Get("/")
+		qt.Assert(t, qt.IsNil(err))
+	}
+
+	qt.Check(t, qt.DeepEquals(*hosts, []string{"up/", "up/", "up/"}))
+	qt.Check(t, qt.Equals(dials.Load(), int32(1)))
+}
+
+func TestLoadBalancerNoFailoverNonIdempotent(t *testing.T) {
+	hosts, _, dial := newBalancerTestServer(t, "down:80")
+
+	c := NewClient(dial, LoadBalancer{URLs: []string{"http://down", "http://up"}})
+
+	_, err := c.Post("/", []byte("data"))
+	qt.Check(t, qt.IsNotNil(err))
+	qt.Check(t, qt.HasLen(*hosts, 0))
+
+	_, err = c.Post("/", []byte("data"))
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.DeepEquals(*hosts, []string{"up/"}))
+}
+
+func TestLoadBalancerAllEndpointsDown(t *testing.T) {
+	_, dials, dial := newBalancerTestServer(t, "a:80", "b:80")
+
+	c := NewClient(dial, LoadBalancer{URLs: []string{"http://a", "http://b"}})
+
+	_, err := c.Get("/")
+	qt.Check(t, qt.ErrorMatches(err, ".*connection refused"))
+	qt.Check(t, qt.Equals(dials.Load(), int32(2)))
+}
+
+func TestLoadBalancerLeastInFlight(t *testing.T) {
+	b := newBalancer(&LoadBalancer{
+		URLs:     []string{"http://a", "http://b", "http://c"},
+		Strategy: BalanceLeastInFlight,
+	}, "http://a")
+
+	b.endpoints[0].inFlight.Store(2)
+	b.endpoints[1].inFlight.Store(1)
+	b.endpoints[2].inFlight.Store(3)
+
+	qt.Check(t, qt.Equals(b.pick(nil).url, "http://b"))
+	qt.Check(t, qt.Equals(b.pick([]*endpoint{b.endpoints[1]}).url, "http://a"))
+}
+
+func TestLoadBalancerEjection(t *testing.T) {
+	b := newBalancer(&LoadBalancer{
+		URLs:             []string{"http://a", "http://b"},
+		FailureThreshold: 2,
+	}, "http://a")
+
+	a := b.endpoints[0]
+
+	qt.Check(t, qt.IsFalse(b.done(a, true)))
+	qt.Check(t, qt.IsTrue(b.done(a, true)))
+
+	for range 3 {
+		qt.Check(t, qt.Equals(b.pick(nil).url, "http://b"))
+	}
+
+	// Ejected endpoint is used when there are no healthy endpoints left.
+	qt.Check(t, qt.Equals(b.pick([]*endpoint{b.endpoints[1]}), a))
+}
+
+func TestLoadBalancerInitialResolution(t *testing.T) {
+	hosts, _, dial := newBalancerTestServer(t)
+
+	c := NewClient(dial, LoadBalancer{
+		URLs:    []string{"http://service.invalid"},
+		Resolve: true,
+		Resolver: &net.Resolver{
+			PreferGo: true,
+			Dial: func(context.Context, string, string) (net.Conn, error) {
+				time.Sleep(50 * time.Millisecond)
+
+				return nil, errors.New("dns unavailable")
+			},
+		},
+	})
+
+	// Concurrent requests wait for the first resolution and fall back to configured URLs.
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			_, err := c.Get("/")
+			qt.Check(t, qt.IsNil(err))
+		})
+	}
+
+	wg.Wait()
+
+	qt.Check(t, qt.HasLen(*hosts, 4))
+}
+
+func TestLoadBalancerResolveTLS(t *testing.T) {
+	ca := newTestCA(t)
+
+	serverCrt, serverKey := ca.issue(t, "service.test", x509.ExtKeyUsageServerAuth)
+	serverCert, err := cert.LoadTLSCertificate(serverCrt, serverKey)
+	qt.Assert(t, qt.IsNil(err))
+
+	rootCAs, err := cert.LoadCertPool(ca.pem)
+	qt.Assert(t, qt.IsNil(err))
+
+	ln := fasthttputil.NewInmemoryListener()
+	defer ln.Close()
+
+	var (
+		lock  sync.Mutex
+		hosts []string
+		addrs []string
+	)
+
+	server := &fasthttp.Server{
+		Logger: log.New(io.Discard, "", 0),
+		Handler: func(ctx *fasthttp.RequestCtx) {
+			lock.Lock()
+			defer lock.Unlock()
+
+			hosts = append(hosts, ctx.TLSConnectionState().ServerName+" "+string(ctx.Host()))
+		},
+	}
+
+	go func() {
+		_ = server.Serve(tls.NewListener(ln, &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{*serverCert},
+		}))
+	}()
+
+	c := NewClient(
+		DialContextFunc(func(_ context.Context, _, addr string) (net.Conn, error) {
+			lock.Lock()
+			addrs = append(addrs, addr)
+			lock.Unlock()
+
+			return ln.Dial()
+		}),
+		&TLSConfig{MinVersion: tls.VersionTLS12, RootCAs: rootCAs},
+		LoadBalancer{
+			URLs:     []string{"https://service.test"},
+			Resolve:  true,
+			Resolver: newTestResolver(map[string][]string{"service.test.": {"10.0.0.1", "10.0.0.2"}}),
+		},
+	)
+
+	for range 2 {
+		_, err := c.Get("/")
+		qt.Assert(t, qt.IsNil(err))
+	}
+
+	// Resolved addresses are dialed and hostname is used for TLS and in the Host header.
+	qt.Check(t, qt.DeepEquals(addrs, []string{"10.0.0.1:443", "10.0.0.2:443"}))
+	qt.Check(t, qt.DeepEquals(hosts, []string{"service.test service.test", "service.test service.test"}))
+}
+
+func TestLoadBalancerStreamInFlight(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			_, _ = w.WriteString("data")
+			_ = w.Flush()
+
+			<-done
+		})
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), LoadBalancer{URLs: []string{"http://a", "http://b"}})
+	ep := c.(*client).balancer.endpoints[0]
+
+	req := c.NewRequest()
+	defer c.ReleaseRequest(req)
+
+	req.SetRequestURI("http://a/")
+
+	resp := c.NewResponse()
+	resp.StreamBody = true
+
+	qt.Assert(t, qt.IsNil(c.Do(req, resp)))
+	qt.Assert(t, qt.IsTrue(resp.IsBodyStream()))
+
+	// Request is in flight while the response body is streamed.
+	qt.Check(t, qt.Equals(ep.inFlight.Load(), int64(1)))
+
+	qt.Check(t, qt.IsNil(resp.CloseBodyStream()))
+	qt.Check(t, qt.Equals(ep.inFlight.Load(), int64(0)))
+
+	c.ReleaseResponse(resp)
+}
+
+func TestLoadBalancerLocalError(t *testing.T) {
+	qt.Check(t, qt.IsTrue(isLocalError(context.Canceled)))
+	qt.Check(t, qt.IsTrue(isLocalError(RateLimitExceededError{})))
+	qt.Check(t, qt.IsTrue(isLocalError(BulkheadFullError{})))
+
+	// Timeouts are recorded against the endpoint.
+	qt.Check(t, qt.IsFalse(isLocalError(context.DeadlineExceeded)))
+	qt.Check(t, qt.IsFalse(isLocalError(fasthttp.ErrTimeout)))
+}
+
+func TestParseBalanceStrategy(t *testing.T) {
+	for _, s := range []BalanceStrategy{BalanceRoundRobin, BalanceRandom, BalanceLeastInFlight} {
+		v, err := ParseBalanceStrategy(s.String())
+		qt.Check(t, qt.IsNil(err))
+		qt.Check(t, qt.Equals(v, s))
+	}
+
+	_, err := ParseBalanceStrategy("fastest")
+	qt.Check(t, qt.IsNotNil(err))
+}
+
+func TestClientWithNamedConfigurationBaseURLs(t *testing.T) {
+	hosts, _, dial := newBalancerTestServer(t)
+
+	c := NewClient(dial, &Configuration{
+		Clients: map[string]NamedClient{
+			"test": {
+				BaseURLs: []string{"http://a/v1", "http://b/v1"},
+			},
+		},
+	})
+
+	nc, err := c.WithConfiguration("test")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(nc.BaseURL(), "http://a/v1"))
+
+	for range 2 {
+		_, err = nc.Get("users")
+		qt.Assert(t, qt.IsNil(err))
+	}
+
+	qt.Check(t, qt.DeepEquals(*hosts, []string{"a/v1/users", "b/v1/users"}))
+}
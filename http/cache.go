@@ -0,0 +1,653 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"azugo.io/core/cache"
+
+	"github.com/valyala/fasthttp"
+)
+
+// InstrumentationCache is the instrumentation operation name for HTTP client response cache events.
+const InstrumentationCache = "http-client-cache"
+
+const (
+	responseCacheName           = "http-client-responses"
+	defaultResponseCacheMaxBody = 1024 * 1024
+	defaultResponseCacheStale   = time.Hour
+	maxHeuristicFreshness       = 24 * time.Hour
+)
+
+// CacheStatus describes how the response was served by the response cache.
+type CacheStatus int
+
+const (
+	// CacheMiss means that response was fetched from the server.
+	CacheMiss CacheStatus = iota
+	// CacheHit means that fresh response was served from the cache.
+	CacheHit
+	// CacheRevalidated means that stale response was validated by the server and served from the cache.
+	CacheRevalidated
+	// CacheStale means that stale response was served from the cache because the request failed.
+	CacheStale
+)
+
+// String returns the name of the cache status.
+func (s CacheStatus) String() string {
+	switch s {
+	case CacheMiss:
+		return "miss"
+	case CacheHit:
+		return "hit"
+	case CacheRevalidated:
+		return "revalidated"
+	case CacheStale:
+		return "stale"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// Cache sets the cache that the client features use to store their data if
+// they do not have their own cache configured.
+type Cache struct {
+	Cache *cache.Cache
+}
+
+func (c Cache) apply(o *options) {
+	o.Cache = c.Cache
+}
+
+// ResponseCache enables caching of GET responses according to RFC 9111.
+//
+// Responses are stored if they have explicit freshness lifetime or validators
+// (ETag or Last-Modified). Stale responses are revalidated with conditional
+// requests and can be served if the request fails and the response allows
+// stale-if-error. Successful requests with unsafe methods invalidate the
+// cached response of the same URL.
+//
+// By default cache acts as a shared cache, so responses with private directive
+// and responses to authorized requests that are not explicitly public are not stored.
+// Requests are considered authorized if they have Authorization, X-Api-Key, Api-Key
+// or X-Auth-Token header, are signed with HMACSigner or AWSSigV4 client option or
+// are sent with TLS client certificate. Responses are cached separately for each
+// named client configuration and base URL.
+type ResponseCache struct {
+	// Cache to store responses in. If not set, cache set with Cache option or in-memory cache is used.
+	Cache *cache.Cache
+	// Private enables storing responses intended for a single user. Enable it
+	// only if the client is not shared between different users.
+	Private bool
+	// StaleIfError is the time stale response can be served on error if the
+	// response does not have stale-if-error directive (defaults to 0).
+	StaleIfError time.Duration
+	// KeepStale is the time stale responses with validators are kept for revalidation (defaults to 1h).
+	KeepStale time.Duration
+	// MaxBodySize is the maximum size of the response body to store (defaults to 1MB).
+	MaxBodySize int
+}
+
+func (c ResponseCache) apply(o *options) {
+	o.ResponseCache = &c
+	o.responses = nil
+}
+
+// cachedResponse is the response stored in the cache.
+type cachedResponse struct {
+	StatusCode   int           `json:"status_code"`
+	Header       [][2]string   `json:"header"`
+	Body         []byte        `json:"body,omitempty"`
+	Vary         [][2]string   `json:"vary,omitempty"`
+	InitialAge   time.Duration `json:"initial_age"`
+	Lifetime     time.Duration `json:"lifetime"`
+	ResponseTime time.Time     `json:"response_time"`
+}
+
+func (e *cachedResponse) peek(key string) string {
+	for _, h := range e.Header {
+		if strings.EqualFold(h[0], key) {
+			return h[1]
+		}
+	}
+
+	return ""
+}
+
+func (e *cachedResponse) cacheControl() cacheControl {
+	var values []string
+
+	for _, h := range e.Header {
+		if strings.EqualFold(h[0], fasthttp.HeaderCacheControl) {
+			values = append(values, h[1])
+		}
+	}
+
+	return parseCacheControl(values...)
+}
+
+func (e *cachedResponse) age(now time.Time) time.Duration {
+	return e.InitialAge + max(now.Sub(e.ResponseTime), 0)
+}
+
+func (e *cachedResponse) hasValidators() bool {
+	return e.peek(fasthttp.HeaderETag) != "" || e.peek(fasthttp.HeaderLastModified) != ""
+}
+
+// matches reports if the request has the same values of the headers the response varies on.
+func (e *cachedResponse) matches(req *Request) bool {
+	for _, v := range e.Vary {
+		if string(req.Header.Peek(v[0])) != v[1] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// writeTo writes the cached response to the response.
+func (e *cachedResponse) writeTo(resp *Response, age time.Duration) {
+	resp.Reset()
+	resp.SetStatusCode(e.StatusCode)
+
+	for _, h := range e.Header {
+		resp.Header.Add(h[0], h[1])
+	}
+
+	resp.Header.Set(fasthttp.HeaderAge, strconv.FormatInt(int64(age/time.Second), 10))
+	resp.SetBody(e.Body)
+}
+
+// update replaces stored headers with the ones from the 304 Not Modified response.
+func (e *cachedResponse) update(resp *Response) {
+	updated := make(map[string]bool)
+
+	for k, v := range resp.Header.All() {
+		key := string(k)
+		if skipCachedHeader(key) {
+			continue
+		}
+
+		if !updated[strings.ToLower(key)] {
+			updated[strings.ToLower(key)] = true
+			e.Header = deleteHeader(e.Header, key)
+		}
+
+		e.Header = append(e.Header, [2]string{key, string(v)})
+	}
+}
+
+func deleteHeader(header [][2]string, key string) [][2]string {
+	res := header[:0]
+
+	for _, h := range header {
+		if !strings.EqualFold(h[0], key) {
+			res = append(res, h)
+		}
+	}
+
+	return res
+}
+
+// skipCachedHeader reports if the response header should not be stored.
+func skipCachedHeader(key string) bool {
+	switch strings.ToLower(key) {
+	case "connection", "keep-alive", "proxy-connection", "transfer-encoding", "upgrade", "te", "trailer",
+		"content-length", "age":
+		return true
+	default:
+		return false
+	}
+}
+
+// cacheControl holds the parsed Cache-Control header directives.
+type cacheControl map[string]string
+
+func parseCacheControl(values ...string) cacheControl {
+	cc := make(cacheControl)
+
+	for _, v := range values {
+		for part := range strings.SplitSeq(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name == "" {
+				continue
+			}
+
+			cc[strings.ToLower(name)] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+
+	return cc
+}
+
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+
+	return ok
+}
+
+// seconds returns the directive value as duration.
+func (cc cacheControl) seconds(name string) (time.Duration, bool) {
+	v, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+
+	return time.Duration(secs) * time.Second, true
+}
+
+func headerValues(h interface{ PeekAll(key string) [][]byte }, key string) []string {
+	all := h.PeekAll(key)
+	values := make([]string, 0, len(all))
+
+	for _, v := range all {
+		values = append(values, string(v))
+	}
+
+	return values
+}
+
+// authorizationHeaders are the request headers with credentials that make the
+// response intended for a single user.
+var authorizationHeaders = []string{
+	fasthttp.HeaderAuthorization,
+	"X-Api-Key",
+	"Api-Key",
+	"X-Auth-Token",
+}
+
+// responseCache stores responses shared between clients derived from the same client.
+type responseCache struct {
+	config *ResponseCache
+	store  *cache.Cache
+	now    func() time.Time
+}
+
+func newResponseCache(config *ResponseCache, store *cache.Cache) *responseCache {
+	c := *config
+
+	if c.Cache != nil {
+		store = c.Cache
+	}
+
+	if c.KeepStale <= 0 {
+		c.KeepStale = defaultResponseCacheStale
+	}
+
+	if c.MaxBodySize <= 0 {
+		c.MaxBodySize = defaultResponseCacheMaxBody
+	}
+
+	if store == nil {
+		store = cache.New(cache.MemoryCache)
+	}
+
+	return &responseCache{
+		config: &c,
+		store:  store,
+		now:    time.Now,
+	}
+}
+
+// cached returns the cache instance. It is created on first use as the cache
+// might not be started yet when the client is created.
+func (rc *responseCache) cached() (cache.Instance[cachedResponse], error) {
+	return cache.GetOrCreate[cachedResponse](rc.store, responseCacheName)
+}
+
+// cacheKey returns the response cache key for the request. Keys are scoped to
+// the client configuration so that clients with different credentials do not
+// share responses.
+func (c client) cacheKey(req *Request) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(c.name))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(c.baseURL))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(req.URI().FullURI())
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// authorized reports if the request is sent with credentials, either in the
+// request headers, by signing it or with the TLS client certificate.
+func (c client) authorized(req *Request) bool {
+	if len(c.Signers) > 0 {
+		return true
+	}
+
+	if tc := c.c.TLSConfig; tc != nil && (len(tc.Certificates) > 0 || tc.GetClientCertificate != nil) {
+		return true
+	}
+
+	for _, h := range authorizationHeaders {
+		if len(req.Header.Peek(h)) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rc *responseCache) get(ctx context.Context, key string, req *Request) (*cachedResponse, bool) {
+	instance, err := rc.cached()
+	if err != nil {
+		return nil, false
+	}
+
+	entry, err := instance.Get(ctx, key)
+	if err != nil || entry.StatusCode == 0 || !entry.matches(req) {
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+func (rc *responseCache) set(ctx context.Context, key string, entry *cachedResponse) {
+	cc := entry.cacheControl()
+
+	keep := max(entry.Lifetime-entry.InitialAge, 0)
+
+	stale := rc.staleIfError(cc)
+	if entry.hasValidators() {
+		stale = max(stale, rc.config.KeepStale)
+	}
+
+	if ttl := keep + stale; ttl > 0 {
+		if instance, err := rc.cached(); err == nil {
+			_ = instance.Set(ctx, key, *entry, cache.TTL[cachedResponse](ttl))
+		}
+	}
+}
+
+func (rc *responseCache) delete(ctx context.Context, key string) {
+	if instance, err := rc.cached(); err == nil {
+		_ = instance.Delete(ctx, key)
+	}
+}
+
+func (rc *responseCache) staleIfError(cc cacheControl) time.Duration {
+	if cc.has("must-revalidate") || (!rc.config.Private && cc.has("proxy-revalidate")) {
+		return 0
+	}
+
+	if d, ok := cc.seconds("stale-if-error"); ok {
+		return d
+	}
+
+	return rc.config.StaleIfError
+}
+
+// heuristicallyCacheable reports if the response status code is cacheable by default (RFC 9110 section 15.1).
+func heuristicallyCacheable(status int) bool {
+	switch status {
+	case fasthttp.StatusOK,
+		fasthttp.StatusNonAuthoritativeInfo,
+		fasthttp.StatusNoContent,
+		fasthttp.StatusMultipleChoices,
+		fasthttp.StatusMovedPermanently,
+		fasthttp.StatusPermanentRedirect,
+		fasthttp.StatusNotFound,
+		fasthttp.StatusMethodNotAllowed,
+		fasthttp.StatusGone,
+		fasthttp.StatusRequestURITooLong,
+		fasthttp.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+// entry returns the cache entry for the response or nil if the response can not be stored.
+func (rc *responseCache) entry(req *Request, resp *Response, authorized bool, requestTime, responseTime time.Time) *cachedResponse {
+	if !heuristicallyCacheable(resp.StatusCode()) || len(resp.Body()) > rc.config.MaxBodySize {
+		return nil
+	}
+
+	cc := parseCacheControl(headerValues(&resp.Header, fasthttp.HeaderCacheControl)...)
+	if cc.has("no-store") || (!rc.config.Private && cc.has("private")) {
+		return nil
+	}
+
+	if !rc.config.Private && authorized &&
+		!cc.has("public") && !cc.has("s-maxage") && !cc.has("must-revalidate") {
+		return nil
+	}
+
+	entry := &cachedResponse{
+		StatusCode:   resp.StatusCode(),
+		Body:         bytes.Clone(resp.Body()),
+		ResponseTime: responseTime,
+	}
+
+	for _, vary := range headerValues(&resp.Header, fasthttp.HeaderVary) {
+		for name := range strings.SplitSeq(vary, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil
+			}
+
+			if name != "" {
+				entry.Vary = append(entry.Vary, [2]string{name, string(req.Header.Peek(name))})
+			}
+		}
+	}
+
+	for k, v := range resp.Header.All() {
+		if key := string(k); !skipCachedHeader(key) {
+			entry.Header = append(entry.Header, [2]string{key, string(v)})
+		}
+	}
+
+	// Age calculation (RFC 9111 section 4.2.3).
+	var ageValue, apparentAge time.Duration
+	if secs, err := strconv.ParseInt(string(resp.Header.Peek(fasthttp.HeaderAge)), 10, 64); err == nil && secs > 0 {
+		ageValue = time.Duration(secs) * time.Second
+	}
+
+	date, err := fasthttp.ParseHTTPDate(resp.Header.Peek(fasthttp.HeaderDate))
+	if err == nil {
+		apparentAge = max(responseTime.Sub(date), 0)
+	} else {
+		date = responseTime
+	}
+
+	entry.InitialAge = max(apparentAge, ageValue+responseTime.Sub(requestTime))
+
+	lifetime, explicit := rc.lifetime(cc, resp, date)
+	if !explicit && !entry.hasValidators() {
+		return nil
+	}
+
+	entry.Lifetime = lifetime
+
+	return entry
+}
+
+// lifetime returns the freshness lifetime of the response and if it was explicitly set (RFC 9111 section 4.2.1).
+func (rc *responseCache) lifetime(cc cacheControl, resp *Response, date time.Time) (time.Duration, bool) {
+	if !rc.config.Private {
+		if d, ok := cc.seconds("s-maxage"); ok {
+			return d, true
+		}
+	}
+
+	if d, ok := cc.seconds("max-age"); ok {
+		return d, true
+	}
+
+	if v := resp.Header.Peek(fasthttp.HeaderExpires); len(v) > 0 {
+		expires, err := fasthttp.ParseHTTPDate(v)
+		if err != nil {
+			// Invalid Expires value means already expired response.
+			return 0, true
+		}
+
+		return max(expires.Sub(date), 0), true
+	}
+
+	if lm, err := fasthttp.ParseHTTPDate(resp.Header.Peek(fasthttp.HeaderLastModified)); err == nil && lm.Before(date) {
+		return min(date.Sub(lm)/10, maxHeuristicFreshness), false
+	}
+
+	return 0, false
+}
+
+// isUnsafeMethod reports if the request method can change the resource state.
+func isUnsafeMethod(req *Request) bool {
+	h := &req.Header
+
+	return h.IsPost() || h.IsPut() || h.IsPatch() || h.IsDelete()
+}
+
+// doCached serves the request from the response cache if possible, otherwise
+// sends it and stores the response.
+func (c client) doCached(ctx context.Context, req *Request, resp *Response) error {
+	rc := c.responses
+	if rc == nil || c.c.StreamResponseBody || resp.StreamBody || req.IsBodyStream() {
+		return c.do(ctx, req, resp)
+	}
+
+	if !req.Header.IsGet() && !req.Header.IsHead() {
+		err := c.do(ctx, req, resp)
+		if err == nil && isUnsafeMethod(req) && resp.StatusCode() < 400 {
+			rc.delete(ctx, c.cacheKey(req))
+		}
+
+		return err
+	}
+
+	reqCC := parseCacheControl(headerValues(&req.Header, fasthttp.HeaderCacheControl)...)
+	if reqCC.has("no-store") {
+		return c.do(ctx, req, resp)
+	}
+
+	key := c.cacheKey(req)
+	entry, ok := rc.get(ctx, key, req)
+
+	var age time.Duration
+
+	if ok {
+		age = entry.age(rc.now())
+		cc := entry.cacheControl()
+
+		fresh := age < entry.Lifetime && !cc.has("no-cache") && !reqCC.has("no-cache")
+		if maxAge, ok := reqCC.seconds("max-age"); ok && age > maxAge {
+			fresh = false
+		}
+
+		if fresh {
+			entry.writeTo(resp, age)
+			c.cacheEvent(ctx, req, CacheHit)
+
+			return nil
+		}
+	}
+
+	var added []string
+
+	if ok && len(req.Header.Peek(fasthttp.HeaderIfNoneMatch)) == 0 && len(req.Header.Peek(fasthttp.HeaderIfModifiedSince)) == 0 {
+		if etag := entry.peek(fasthttp.HeaderETag); etag != "" {
+			req.Header.Set(fasthttp.HeaderIfNoneMatch, etag)
+			added = append(added, fasthttp.HeaderIfNoneMatch)
+		}
+
+		if lm := entry.peek(fasthttp.HeaderLastModified); lm != "" {
+			req.Header.Set(fasthttp.HeaderIfModifiedSince, lm)
+			added = append(added, fasthttp.HeaderIfModifiedSince)
+		}
+	}
+
+	requestTime := rc.now()
+	err := c.do(ctx, req, resp)
+	responseTime := rc.now()
+
+	for _, h := range added {
+		req.Header.Del(h)
+	}
+
+	if ctx.Err() != nil {
+		return err
+	}
+
+	if ok && err == nil && len(added) > 0 && resp.StatusCode() == fasthttp.StatusNotModified {
+		entry.update(resp)
+
+		if updated := rc.entry(req, &Response{Response: entryResponse(entry)}, c.authorized(req), requestTime, responseTime); updated != nil {
+			entry = updated
+			rc.set(ctx, key, entry)
+		} else {
+			rc.delete(ctx, key)
+		}
+
+		entry.writeTo(resp, entry.InitialAge)
+		c.cacheEvent(ctx, req, CacheRevalidated)
+
+		return nil
+	}
+
+	if ok && (err != nil || resp.StatusCode() >= 500) {
+		if age < entry.Lifetime+rc.staleIfError(entry.cacheControl()) {
+			entry.writeTo(resp, age)
+			c.cacheEvent(ctx, req, CacheStale)
+
+			return nil
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.cacheEvent(ctx, req, CacheMiss)
+
+	if req.Header.IsGet() {
+		if e := rc.entry(req, resp, c.authorized(req), requestTime, responseTime); e != nil {
+			rc.set(ctx, key, e)
+		}
+	}
+
+	return nil
+}
+
+// entryResponse returns the response with stored status, headers and body.
+func entryResponse(entry *cachedResponse) *fasthttp.Response {
+	resp := &fasthttp.Response{}
+	resp.SetStatusCode(entry.StatusCode)
+
+	for _, h := range entry.Header {
+		resp.Header.Add(h[0], h[1])
+	}
+
+	resp.SetBody(entry.Body)
+
+	return resp
+}
+
+// cacheEvent reports response cache event to the instrumenter.
+func (c client) cacheEvent(ctx context.Context, req *Request, status CacheStatus) {
+	c.Instrumenter.Observe(ctx, InstrumentationCache, req, status)(nil)
+}
+
+// InstrCache returns request and cache status if the operation is HTTP client response cache event.
+func InstrCache(op string, args ...any) (*Request, CacheStatus, bool) {
+	if op != InstrumentationCache || len(args) != 2 {
+		return nil, 0, false
+	}
+
+	req, ok1 := args[0].(*Request)
+	status, ok2 := args[1].(CacheStatus)
+
+	return req, status, ok1 && ok2
+}
@@ -0,0 +1,338 @@
+package http
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"azugo.io/core/cache"
+
+	"github.com/go-quicktest/qt"
+	"github.com/valyala/fasthttp"
+)
+
+type cacheTestClient struct {
+	Client
+
+	lock     sync.Mutex
+	statuses []CacheStatus
+	now      time.Time
+}
+
+func newCacheTestClient(t *testing.T, handler fasthttp.RequestHandler, config ResponseCache) *cacheTestClient {
+	t.Helper()
+
+	s := newTestHttpServer()
+	s.Handler = handler
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ca := cache.New(cache.MemoryCache)
+	qt.Assert(t, qt.IsNil(ca.Start(context.Background())))
+	t.Cleanup(ca.Close)
+
+	tc := &cacheTestClient{now: time.Now()}
+
+	config.Cache = ca
+
+	c := NewClient(s.DialContext(), config, Instrumenter(func(_ context.Context, op string, args ...any) func(error) {
+		if _, status, ok := InstrCache(op, args...); ok {
+			tc.lock.Lock()
+			tc.statuses = append(tc.statuses, status)
+			tc.lock.Unlock()
+		}
+
+		return func(error) {}
+	}))
+
+	c.(*client).responses.now = func() time.Time {
+		tc.lock.Lock()
+		defer tc.lock.Unlock()
+
+		return tc.now
+	}
+
+	tc.Client = c
+
+	return tc
+}
+
+func (c *cacheTestClient) get(t *testing.T, url string, opts ...RequestOption) string {
+	t.Helper()
+
+	body, err := c.Get(url, opts...)
+	qt.Assert(t, qt.IsNil(err))
+
+	// Wait for memory cache to store the value.
+	time.Sleep(10 * time.Millisecond)
+
+	return string(body)
+}
+
+func (c *cacheTestClient) advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestResponseCacheMaxAge(t *testing.T) {
+	var hits atomic.Int32
+
+	c := newCacheTestClient(t, func(ctx *fasthttp.RequestCtx) {
+		hits.Add(1)
+		ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "max-age=60")
+		ctx.SetBodyString("data")
+	}, ResponseCache{})
+
+	for range 3 {
+		qt.Check(t, qt.Equals(c.get(t, "http://localhost/items"), "data"))
+	}
+
+	qt.Check(t, qt.Equals(hits.Load(), int32(1)))
+
+	c.advance(61 * time.Second)
+
+	qt.Check(t, qt.Equals(c.get(t, "http://localhost/items"), "data"))
+	qt.Check(t, qt.Equals(hits.Load(), int32(2)))
+	qt.Check(t, qt.DeepEquals(c.statuses, []CacheStatus{CacheMiss, CacheHit, CacheHit, CacheMiss}))
+}
+
+func TestResponseCacheNoStore(t *testing.T) {
+	var hits atomic.Int32
+
+	c := newCacheTestClient(t, func(ctx *fasthttp.RequestCtx) {
+		hits.Add(1)
+
+		if string(ctx.Path()) == "/no-store" {
+			ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "no-store, max-age=60")
+		} else {
+			ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "max-age=60")
+		}
+	}, ResponseCache{})
+
+	c.get(t, "http://localhost/no-store")
+	c.get(t, "http://localhost/no-store")
+	qt.Check(t, qt.Equals(hits.Load(), int32(2)))
+
+	c.get(t, "http://localhost/items", WithHeader(fasthttp.HeaderCacheControl, "no-store"))
+	c.get(t, "http://localhost/items", WithHeader(fasthttp.HeaderCacheControl, "no-store"))
+	qt.Check(t, qt.Equals(hits.Load(), int32(4)))
+}
+
+func TestResponseCacheRevalidate(t *testing.T) {
+	var (
+		hits        atomic.Int32
+		notModified atomic.Int32
+	)
+
+	c := newCacheTestClient(t, func(ctx *fasthttp.RequestCtx) {
+		hits.Add(1)
+
+		ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "no-cache")
+		ctx.Response.Header.Set(fasthttp.HeaderETag, `"v1"`)
+
+		if string(ctx.Request.Header.Peek(fasthttp.HeaderIfNoneMatch)) == `"v1"` {
+			notModified.Add(1)
+			ctx.SetStatusCode(fasthttp.StatusNotModified)
+
+			return
+		}
+
+		ctx.SetBodyString("data")
+	}, ResponseCache{})
+
+	qt.Check(t, qt.Equals(c.get(t, "http://localhost/items"), "data"))
+	qt.Check(t, qt.Equals(c.get(t, "http://localhost/items"), "data"))
+	qt.Check(t, qt.Equals(hits.Load(), int32(2)))
+	qt.Check(t, qt.Equals(notModified.Load(), int32(1)))
+	qt.Check(t, qt.DeepEquals(c.statuses, []CacheStatus{CacheMiss, CacheRevalidated}))
+}
+
+func TestResponseCacheStaleIfError(t *testing.T) {
+	var fail atomic.Bool
+
+	c := newCacheTestClient(t, func(ctx *fasthttp.RequestCtx) {
+		if fail.Load() {
+			ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+
+			return
+		}
+
+		ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "max-age=1, stale-if-error=60")
+		ctx.SetBodyString("data")
+	}, ResponseCache{})
+
+	c.get(t, "http://localhost/items")
+
+	fail.Store(true)
+	c.advance(30 * time.Second)
+
+	qt.Check(t, qt.Equals(c.get(t, "http://localhost/items"), "data"))
+
+	c.advance(time.Minute)
+
+	_, err := c.Get("http://localhost/items")
+	qt.Check(t, qt.ErrorAs(err, new(ServerError)))
+	qt.Check(t, qt.DeepEquals(c.statuses, []CacheStatus{CacheMiss, CacheStale, CacheMiss}))
+}
+
+func TestResponseCacheVary(t *testing.T) {
+	var hits atomic.Int32
+
+	c := newCacheTestClient(t, func(ctx *fasthttp.RequestCtx) {
+		hits.Add(1)
+		ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "max-age=60")
+		ctx.Response.Header.Set(fasthttp.HeaderVary, fasthttp.HeaderAcceptLanguage)
+		ctx.SetBody(ctx.Request.Header.Peek(fasthttp.HeaderAcceptLanguage))
+	}, ResponseCache{})
+
+	qt.Check(t, qt.Equals(c.get(t, "http://localhost/items", WithHeader(fasthttp.HeaderAcceptLanguage, "en")), "en"))
+	qt.Check(t, qt.Equals(c.get(t, "http://localhost/items", WithHeader(fasthttp.HeaderAcceptLanguage, "en")), "en"))
+	qt.Check(t, qt.Equals(c.get(t, "http://localhost/items", WithHeader(fasthttp.HeaderAcceptLanguage, "lv")), "lv"))
+	qt.Check(t, qt.Equals(hits.Load(), int32(2)))
+}
+
+func TestResponseCachePrivate(t *testing.T) {
+	handler := func(hits *atomic.Int32) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			hits.Add(1)
+			ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "private, max-age=60")
+		}
+	}
+
+	var shared, private atomic.Int32
+
+	c := newCacheTestClient(t, handler(&shared), ResponseCache{})
+	c.get(t, "http://localhost/items")
+	c.get(t, "http://localhost/items")
+	qt.Check(t, qt.Equals(shared.Load(), int32(2)))
+
+	c = newCacheTestClient(t, handler(&private), ResponseCache{Private: true})
+	c.get(t, "http://localhost/items")
+	c.get(t, "http://localhost/items")
+	qt.Check(t, qt.Equals(private.Load(), int32(1)))
+}
+
+func TestResponseCacheAuthorized(t *testing.T) {
+	var hits atomic.Int32
+
+	c := newCacheTestClient(t, func(ctx *fasthttp.RequestCtx) {
+		hits.Add(1)
+
+		if ctx.QueryArgs().Has("public") {
+			ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "public, max-age=60")
+		} else {
+			ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "max-age=60")
+		}
+	}, ResponseCache{})
+
+	c.get(t, "http://localhost/items", WithHeader("X-Api-Key", "key"))
+	c.get(t, "http://localhost/items", WithHeader("X-Api-Key", "key"))
+	qt.Check(t, qt.Equals(hits.Load(), int32(2)))
+
+	signed := &cacheTestClient{Client: c.WithOptions(HMACSigner{Secret: []byte("secret")})}
+
+	signed.get(t, "http://localhost/items")
+	signed.get(t, "http://localhost/items")
+	qt.Check(t, qt.Equals(hits.Load(), int32(4)))
+
+	// Explicitly public responses are stored.
+	signed.get(t, "http://localhost/items?public")
+	signed.get(t, "http://localhost/items?public")
+	qt.Check(t, qt.Equals(hits.Load(), int32(5)))
+}
+
+func TestResponseCacheClientScope(t *testing.T) {
+	var hits atomic.Int32
+
+	c := newCacheTestClient(t, func(ctx *fasthttp.RequestCtx) {
+		hits.Add(1)
+		ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "max-age=60")
+	}, ResponseCache{})
+
+	c.get(t, "http://localhost/items")
+	c.get(t, "http://localhost/items")
+	qt.Check(t, qt.Equals(hits.Load(), int32(1)))
+
+	// Clients with other base URL do not share cached responses.
+	other := &cacheTestClient{Client: c.WithOptions(BaseURL("http://localhost/v2"))}
+
+	other.get(t, "http://localhost/items")
+	other.get(t, "http://localhost/items")
+	qt.Check(t, qt.Equals(hits.Load(), int32(2)))
+}
+
+func TestResponseCacheNamedClientsConcurrent(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "max-age=60")
+	}
+	s.Start()
+	defer s.Stop()
+
+	ca := cache.New(cache.MemoryCache)
+	qt.Assert(t, qt.IsNil(ca.Start(context.Background())))
+	defer ca.Close()
+
+	c := NewClient(s.DialContext(), Cache{ca}, ResponseCache{}, &Configuration{
+		Clients: map[string]NamedClient{
+			"a": {BaseURL: "http://localhost/a"},
+			"b": {BaseURL: "http://localhost/b"},
+		},
+	})
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"a", "b", "a", "b"} {
+		wg.Go(func() {
+			named, err := c.WithConfiguration(name)
+			if !qt.Check(t, qt.IsNil(err)) {
+				return
+			}
+
+			_, err = named.Get("/items")
+			qt.Check(t, qt.IsNil(err))
+		})
+	}
+
+	wg.Wait()
+}
+
+func TestResponseCacheInvalidate(t *testing.T) {
+	var hits atomic.Int32
+
+	c := newCacheTestClient(t, func(ctx *fasthttp.RequestCtx) {
+		hits.Add(1)
+		ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "max-age=60")
+	}, ResponseCache{})
+
+	c.get(t, "http://localhost/items")
+	c.get(t, "http://localhost/items")
+	qt.Check(t, qt.Equals(hits.Load(), int32(1)))
+
+	_, err := c.Post("http://localhost/items", []byte("data"))
+	qt.Assert(t, qt.IsNil(err))
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.get(t, "http://localhost/items")
+	qt.Check(t, qt.Equals(hits.Load(), int32(3)))
+}
+
+func TestParseCacheControl(t *testing.T) {
+	cc := parseCacheControl(`public, max-age=60`, `Stale-If-Error="120", no-cache`)
+
+	qt.Check(t, qt.IsTrue(cc.has("public")))
+	qt.Check(t, qt.IsTrue(cc.has("no-cache")))
+	qt.Check(t, qt.IsFalse(cc.has("private")))
+
+	d, ok := cc.seconds("max-age")
+	qt.Check(t, qt.IsTrue(ok))
+	qt.Check(t, qt.Equals(d, 60*time.Second))
+
+	d, ok = cc.seconds("stale-if-error")
+	qt.Check(t, qt.IsTrue(ok))
+	qt.Check(t, qt.Equals(d, 2*time.Minute))
+}
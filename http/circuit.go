@@ -0,0 +1,340 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// InstrumentationCircuitState is the instrumentation operation name for HTTP client circuit breaker state changes.
+const InstrumentationCircuitState = "http-client-circuit-state"
+
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitOpenTimeout      = 30 * time.Second
+)
+
+// CircuitState represents the circuit breaker state.
+type CircuitState int
+
+const (
+	// CircuitClosed state allows all requests.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen state rejects all requests.
+	CircuitOpen
+	// CircuitHalfOpen state allows limited number of trial requests.
+	CircuitHalfOpen
+)
+
+// String returns the name of the circuit breaker state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// CircuitScope defines how circuits are grouped.
+type CircuitScope int
+
+const (
+	// CircuitPerHost uses separate circuit for each upstream host.
+	CircuitPerHost CircuitScope = iota
+	// CircuitPerClient uses separate circuit for each named client configuration.
+	// Requests made without named configuration fall back to per host circuits.
+	CircuitPerClient
+)
+
+// CircuitOpenError is returned when request is rejected because the circuit is open.
+type CircuitOpenError struct {
+	// Key is the host or named client the circuit belongs to.
+	Key string
+	// RetryAfter is the time left until the circuit allows trial requests.
+	RetryAfter time.Duration
+}
+
+func (e CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open for %q", e.Key)
+}
+
+// CircuitBreaker configures circuit breaker for the HTTP client.
+//
+// After FailureThreshold consecutive failures the circuit opens and all requests
+// fail immediately with CircuitOpenError. After OpenTimeout the circuit becomes
+// half-open and allows limited number of trial requests. If trial requests succeed
+// the circuit is closed, otherwise it is opened again.
+type CircuitBreaker struct {
+	// Scope defines if circuits are kept per host or per named client (defaults to per host).
+	Scope CircuitScope
+	// FailureThreshold is the number of consecutive failures that opens the circuit (defaults to 5).
+	FailureThreshold int
+	// SuccessThreshold is the number of successful trial requests that closes the circuit (defaults to 1).
+	SuccessThreshold int
+	// HalfOpenMaxRequests is the maximum number of concurrent trial requests
+	// allowed in half-open state (defaults to 1).
+	HalfOpenMaxRequests int
+	// OpenTimeout is the time the circuit stays open before allowing trial requests (defaults to 30s).
+	OpenTimeout time.Duration
+	// IsFailure reports if the request result should be counted as failure.
+	// By default errors and 5xx response status codes are counted as failures.
+	// Canceled requests are counted neither as failures nor as successes.
+	IsFailure func(resp *Response, err error) bool
+}
+
+func (b CircuitBreaker) apply(o *options) {
+	o.CircuitBreaker = &b
+	o.circuits = nil
+}
+
+func defaultCircuitIsFailure(resp *Response, err error) bool {
+	return err != nil || resp.StatusCode() >= 500
+}
+
+type circuit struct {
+	lock  sync.Mutex
+	state CircuitState
+	// generation is incremented on every state change so that results of
+	// requests allowed before the change are not counted in the new state.
+	generation uint64
+	failures   int
+	successes  int
+	trials     int
+	openedAt   time.Time
+}
+
+// circuitTicket is the permission to send the request given by the circuit.
+type circuitTicket struct {
+	item       *circuit
+	generation uint64
+}
+
+// circuits holds circuit breakers states shared between clients derived from the same client.
+type circuits struct {
+	config *CircuitBreaker
+	lock   sync.Mutex
+	items  map[string]*circuit
+	now    func() time.Time
+}
+
+func newCircuits(config *CircuitBreaker) *circuits {
+	c := *config
+
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = defaultCircuitFailureThreshold
+	}
+
+	if c.SuccessThreshold <= 0 {
+		c.SuccessThreshold = 1
+	}
+
+	if c.HalfOpenMaxRequests <= 0 {
+		c.HalfOpenMaxRequests = 1
+	}
+
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = defaultCircuitOpenTimeout
+	}
+
+	if c.IsFailure == nil {
+		c.IsFailure = defaultCircuitIsFailure
+	}
+
+	return &circuits{
+		config: &c,
+		items:  make(map[string]*circuit),
+		now:    time.Now,
+	}
+}
+
+func (c *circuits) get(key string) *circuit {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	item, ok := c.items[key]
+	if !ok {
+		item = &circuit{}
+		c.items[key] = item
+	}
+
+	return item
+}
+
+// state returns the current circuit state for the key.
+func (c *circuits) state(key string) CircuitState {
+	item := c.get(key)
+
+	item.lock.Lock()
+	defer item.lock.Unlock()
+
+	if item.state == CircuitOpen && c.now().Sub(item.openedAt) >= c.config.OpenTimeout {
+		return CircuitHalfOpen
+	}
+
+	return item.state
+}
+
+// circuitTransition describes the circuit state change.
+type circuitTransition struct {
+	From, To CircuitState
+}
+
+// allow checks if request is allowed by the circuit. If allowed, done or release
+// must be called with the returned ticket.
+func (c *circuits) allow(key string) (*circuitTicket, *circuitTransition, error) {
+	item := c.get(key)
+
+	item.lock.Lock()
+	defer item.lock.Unlock()
+
+	var tr *circuitTransition
+
+	if item.state == CircuitOpen {
+		left := c.config.OpenTimeout - c.now().Sub(item.openedAt)
+		if left > 0 {
+			return nil, nil, CircuitOpenError{Key: key, RetryAfter: left}
+		}
+
+		item.state = CircuitHalfOpen
+		item.generation++
+		item.successes = 0
+		item.trials = 0
+		tr = &circuitTransition{From: CircuitOpen, To: CircuitHalfOpen}
+	}
+
+	if item.state == CircuitHalfOpen {
+		if item.trials >= c.config.HalfOpenMaxRequests {
+			return nil, tr, CircuitOpenError{Key: key}
+		}
+
+		item.trials++
+	}
+
+	return &circuitTicket{item: item, generation: item.generation}, tr, nil
+}
+
+// done records the request result and returns state transition if the state has changed.
+// Results of requests allowed before the last state change are ignored.
+func (c *circuits) done(t *circuitTicket, failure bool) *circuitTransition {
+	item := t.item
+
+	item.lock.Lock()
+	defer item.lock.Unlock()
+
+	if t.generation != item.generation {
+		return nil
+	}
+
+	switch item.state {
+	case CircuitClosed:
+		if !failure {
+			item.failures = 0
+
+			return nil
+		}
+
+		item.failures++
+		if item.failures < c.config.FailureThreshold {
+			return nil
+		}
+
+		return c.open(item, CircuitClosed)
+	case CircuitHalfOpen:
+		if item.trials > 0 {
+			item.trials--
+		}
+
+		if failure {
+			return c.open(item, CircuitHalfOpen)
+		}
+
+		item.successes++
+		if item.successes < c.config.SuccessThreshold {
+			return nil
+		}
+
+		item.state = CircuitClosed
+		item.generation++
+		item.failures = 0
+
+		return &circuitTransition{From: CircuitHalfOpen, To: CircuitClosed}
+	default:
+		return nil
+	}
+}
+
+// release frees the trial slot without recording the request result.
+func (c *circuits) release(t *circuitTicket) {
+	item := t.item
+
+	item.lock.Lock()
+	defer item.lock.Unlock()
+
+	if t.generation == item.generation && item.state == CircuitHalfOpen && item.trials > 0 {
+		item.trials--
+	}
+}
+
+func (c *circuits) open(item *circuit, from CircuitState) *circuitTransition {
+	item.state = CircuitOpen
+	item.generation++
+	item.openedAt = c.now()
+	item.failures = 0
+	item.successes = 0
+	item.trials = 0
+
+	return &circuitTransition{From: from, To: CircuitOpen}
+}
+
+// circuitKey returns circuit key for the request.
+func (c client) circuitKey(req *Request) string {
+	if c.circuits.config.Scope == CircuitPerClient && c.name != "" {
+		return c.name
+	}
+
+	return string(req.URI().Host())
+}
+
+// circuitChanged reports circuit state change to the instrumenter and logger.
+func (c client) circuitChanged(ctx context.Context, key string, tr *circuitTransition) {
+	if tr == nil {
+		return
+	}
+
+	c.Instrumenter.Observe(ctx, InstrumentationCircuitState, key, tr.From, tr.To)(nil)
+
+	fields := []zap.Field{
+		zap.String("circuit", key),
+		zap.Stringer("from", tr.From),
+		zap.Stringer("to", tr.To),
+	}
+
+	if tr.To == CircuitOpen {
+		c.Logger.Warn("HTTP client circuit breaker opened", fields...)
+
+		return
+	}
+
+	c.Logger.Info("HTTP client circuit breaker state changed", fields...)
+}
+
+// InstrCircuitState returns circuit key and state change if the operation is HTTP client circuit breaker state change event.
+func InstrCircuitState(op string, args ...any) (string, CircuitState, CircuitState, bool) {
+	if op != InstrumentationCircuitState || len(args) != 3 {
+		return "", 0, 0, false
+	}
+
+	key, ok1 := args[0].(string)
+	from, ok2 := args[1].(CircuitState)
+	to, ok3 := args[2].(CircuitState)
+
+	return key, from, to, ok1 && ok2 && ok3
+}
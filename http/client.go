@@ -7,14 +7,19 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net"
 	"runtime/debug"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
+	"azugo.io/core/cache"
 	"azugo.io/core/instrumenter"
 
 	"github.com/valyala/bytebufferpool"
 	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
 )
 
 var (
@@ -25,7 +30,8 @@ var (
 
 // Instrumentation operation names for HTTP client events.
 const (
-	InstrumentationRequest = "http-client-request"
+	InstrumentationRequest      = "http-client-request"
+	InstrumentationRequestQueue = "http-client-request-queue"
 )
 
 // Client is the interface that provides HTTP client.
@@ -71,6 +77,8 @@ type Client interface {
 	PostForm(url string, form map[string][]string, opt ...RequestOption) ([]byte, error)
 	// PostMultipartForm sends an HTTP POST request with multipart form data and returns an HTTP response body.
 	PostMultipartForm(url string, form *multipart.Form, opt ...RequestOption) ([]byte, error)
+	// PostMultipartFormStream sends an HTTP POST request with multipart form data streamed from readers and returns an HTTP response body.
+	PostMultipartFormStream(url string, form *MultipartForm, opt ...RequestOption) ([]byte, error)
 	// Put sends an HTTP PUT request and returns an HTTP response body.
 	Put(url string, body []byte, opt ...RequestOption) ([]byte, error)
 	// PutJSON sends an HTTP PUT request and unmarshals response body into v.
@@ -83,6 +91,12 @@ type Client interface {
 	Delete(url string, opt ...RequestOption) ([]byte, error)
 	// DeleteJSON sends an HTTP DELETE request and unmarshals response body into v.
 	DeleteJSON(url string, body, v any, opt ...RequestOption) error
+	// Upload sends an HTTP PUT request with body streamed from the reader and returns an HTTP response body.
+	Upload(url string, body io.Reader, size int, opt ...RequestOption) ([]byte, error)
+	// Download sends an HTTP GET request and writes the response body to w.
+	Download(url string, w io.Writer, opt ...RequestOption) error
+	// DownloadFile sends an HTTP GET request and writes the response body to the file.
+	DownloadFile(url, path string, opt ...RequestOption) error
 }
 
 // ClientProvider is the interface that provides HTTP client.
@@ -92,18 +106,48 @@ type ClientProvider interface {
 }
 
 type clientOpts struct {
-	RequestPool   sync.Pool
-	ResponsePool  sync.Pool
-	BufferPool    bytebufferpool.Pool
-	RequestMod    []RequestFunc
-	ResponseMod   []ResponseFunc
-	Configuration *Configuration
-	Instrumenter  instrumenter.Instrumenter
+	RequestPool        sync.Pool
+	ResponsePool       sync.Pool
+	BufferPool         bytebufferpool.Pool
+	RequestMod         []RequestFunc
+	ResponseMod        []ResponseFunc
+	Configuration      *Configuration
+	Instrumenter       instrumenter.Instrumenter
+	Retry              *Retry
+	Logger             *zap.Logger
+	Dial               fasthttp.DialFunc
+	Proxy              *ProxyConfig
+	Timeouts           Timeouts
+	RateLimit          *RateLimit
+	OAuth2             *OAuth2ClientCredentials
+	ErrorDecoders      []ErrorDecoder
+	NamedErrorDecoders map[string][]ErrorDecoder
+	RequestLogging     *RequestLogging
+	NoTracePropagation bool
+	Signers            []RequestFunc
+	Bulkhead           *Bulkhead
+	LoadBalancer       *LoadBalancer
+	Cache              *cache.Cache
+	ResponseCache      *ResponseCache
+
+	tlsFiles   *tlsFiles
+	tlsError   error
+	circuits   *circuits
+	limiter    *rateLimiter
+	bulkhead   *bulkhead
+	balancer   *balancer
+	responses  *responseCache
+	tokens     *tokenSource
+	requestLog *requestLogger
+	abort      *abortTransport
+	named      sync.Map
 }
 
 type client struct {
 	*clientOpts
 	c       *fasthttp.Client
+	s       *fasthttp.Client
+	name    string
 	baseURL string
 	ctx     context.Context
 }
@@ -133,75 +177,415 @@ func NewClient(opt ...Option) Client {
 	}
 	opts.apply(opt)
 
+	return newClient(opts)
+}
+
+func newClient(opts *options) *client {
 	if opts.UserAgent == "" {
 		opts.UserAgent = defaultUserAgent
 	}
 
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
+
+	if opts.circuits == nil && opts.CircuitBreaker != nil {
+		opts.circuits = newCircuits(opts.CircuitBreaker)
+	}
+
+	if opts.limiter == nil && opts.RateLimit != nil {
+		opts.limiter = newRateLimiter(opts.RateLimit)
+	}
+
+	if opts.bulkhead == nil && opts.Bulkhead != nil {
+		opts.bulkhead = newBulkhead(opts.Bulkhead)
+	}
+
+	if opts.LoadBalancer != nil {
+		if opts.BaseURL == "" && len(opts.LoadBalancer.URLs) > 0 {
+			opts.BaseURL = opts.LoadBalancer.URLs[0]
+		}
+
+		if opts.balancer == nil {
+			opts.balancer = newBalancer(opts.LoadBalancer, opts.BaseURL)
+		}
+	}
+
+	if opts.responses == nil && opts.ResponseCache != nil {
+		opts.responses = newResponseCache(opts.ResponseCache, opts.Cache)
+	}
+
+	if opts.tokens == nil && opts.OAuth2 != nil {
+		opts.tokens = newTokenSource(opts.OAuth2, opts)
+	}
+
+	var requestLog *requestLogger
+	if opts.RequestLogging != nil {
+		requestLog = newRequestLogger(opts.RequestLogging)
+	}
+
 	retryIfErr := opts.RetryIf
 	if retryIfErr == nil {
 		retryIfErr = defaultRetryIfErr
 	}
 
+	abort := newAbortTransport(opts.Transport)
+
 	return &client{
 		clientOpts: &clientOpts{
-			RequestMod:    opts.RequestModifiers,
-			ResponseMod:   opts.ResponseModifiers,
-			Configuration: opts.Configuration,
-			Instrumenter:  opts.Instrumenter,
-		},
-		c: &fasthttp.Client{
-			Name:               opts.UserAgent,
-			TLSConfig:          opts.TLSConfig,
+			RequestMod:         opts.RequestModifiers,
+			ResponseMod:        opts.ResponseModifiers,
+			Configuration:      opts.Configuration,
+			Instrumenter:       opts.Instrumenter,
+			Retry:              opts.Retry,
+			Logger:             opts.Logger,
 			Dial:               opts.Dial,
-			Transport:          opts.Transport,
-			RetryIfErr:         retryIfErr,
-			StreamResponseBody: opts.StreamResponse,
+			Proxy:              opts.Proxy,
+			Timeouts:           opts.Timeouts,
+			RateLimit:          opts.RateLimit,
+			OAuth2:             opts.OAuth2,
+			ErrorDecoders:      opts.ErrorDecoders,
+			NamedErrorDecoders: opts.NamedErrorDecoders,
+			RequestLogging:     opts.RequestLogging,
+			NoTracePropagation: opts.NoTracePropagation,
+			Signers:            opts.Signers,
+			Bulkhead:           opts.Bulkhead,
+			LoadBalancer:       opts.LoadBalancer,
+			Cache:              opts.Cache,
+			ResponseCache:      opts.ResponseCache,
+			tlsFiles:           opts.tlsFiles,
+			tlsError:           opts.tlsError,
+			circuits:           opts.circuits,
+			limiter:            opts.limiter,
+			bulkhead:           opts.bulkhead,
+			balancer:           opts.balancer,
+			responses:          opts.responses,
+			tokens:             opts.tokens,
+			requestLog:         requestLog,
+			abort:              abort,
 		},
+		c:       newFastHTTPClient(opts, abort, retryIfErr, false),
+		s:       newFastHTTPClient(opts, abort, retryIfErr, true),
+		name:    opts.ConfigurationName,
 		baseURL: opts.BaseURL,
 		ctx:     opts.Context,
 	}
 }
 
+// newFastHTTPClient creates the underlying HTTP client. Streaming client is used
+// for responses with StreamBody set and reads only response bodies up to the
+// prefetch size into memory.
+func newFastHTTPClient(opts *options, transport fasthttp.RoundTripper, retryIfErr fasthttp.RetryIfErrFunc, stream bool) *fasthttp.Client {
+	c := &fasthttp.Client{
+		Name:                opts.UserAgent,
+		TLSConfig:           opts.TLSConfig,
+		Dial:                dialFunc(opts),
+		Transport:           transport,
+		RetryIfErr:          retryIfErr,
+		StreamResponseBody:  stream || opts.StreamResponse,
+		ReadTimeout:         opts.Timeouts.Read,
+		WriteTimeout:        opts.Timeouts.Write,
+		MaxIdleConnDuration: opts.Timeouts.Idle,
+	}
+
+	if stream {
+		c.MaxResponseBodySize = streamPrefetchSize
+	}
+
+	if b, files := opts.balancer, opts.tlsFiles; b != nil || files != nil {
+		c.ConfigureClient = func(hc *fasthttp.HostClient) error {
+			if b != nil {
+				hc.TLSConfig = b.tlsConfig(hc.TLSConfig, hc.Addr)
+			}
+
+			if files != nil {
+				hc.TLSConfig = files.hostConfig(hc.TLSConfig, hc.Addr)
+			}
+
+			return nil
+		}
+	}
+
+	return c
+}
+
+// dialFunc returns dial function for the client using proxy and dial timeout if configured.
+func dialFunc(opts *options) fasthttp.DialFunc {
+	dial := opts.Dial
+
+	if dial == nil && opts.Timeouts.Dial > 0 {
+		dial = func(addr string) (net.Conn, error) {
+			return fasthttp.DialTimeout(addr, opts.Timeouts.Dial)
+		}
+	}
+
+	if opts.Proxy != nil {
+		return newProxyDialer(opts.Proxy, dial, opts.Timeouts.Dial).Dial
+	}
+
+	return dial
+}
+
 func (c client) Do(req *Request, resp *Response) error {
-	if c.ctx.Err() != nil {
-		return c.ctx.Err()
+	if c.tlsError != nil {
+		return c.tlsError
+	}
+
+	ctx, cancel := c.requestContext(req)
+	defer cancel()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if !c.NoTracePropagation {
+		ctx = propagate(ctx, req)
 	}
 
 	for _, f := range c.RequestMod {
-		if err := f(c.ctx, req); err != nil {
+		if err := f(ctx, req); err != nil {
 			return err
 		}
 
-		if c.ctx.Err() != nil {
-			return c.ctx.Err()
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
 	}
 
-	finish := c.Instrumenter.Observe(c.ctx, InstrumentationRequest, req, resp)
+	var auth string
 
-	err := c.c.Do(req.Request, resp.Response)
+	if c.tokens != nil && len(req.Header.Peek(fasthttp.HeaderAuthorization)) == 0 {
+		token, err := c.tokens.acquire(ctx, "")
+		if err != nil {
+			return err
+		}
 
-	if c.ctx.Err() != nil {
-		finish(c.ctx.Err())
+		auth = token.header()
+		req.Header.Set(fasthttp.HeaderAuthorization, auth)
+	}
 
-		return c.ctx.Err()
+	// Body stream is consumed when the request is sent.
+	stream := req.IsBodyStream()
+
+	err := c.doCached(ctx, req, resp)
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
 
-	finish(err)
+	if err == nil && auth != "" && resp.StatusCode() == fasthttp.StatusUnauthorized {
+		// Token might have been revoked before its expiry, retry once with a new token
+		// unless the request body stream has already been consumed.
+		token, terr := c.tokens.acquire(ctx, auth)
+		if terr != nil {
+			return terr
+		}
+
+		if !stream {
+			req.Header.Set(fasthttp.HeaderAuthorization, token.header())
+			resetResponse(resp)
+
+			err = c.do(ctx, req, resp)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+	}
 
 	for _, f := range c.ResponseMod {
-		if e := f(c.ctx, resp, err); e != nil {
+		if e := f(ctx, resp, err); e != nil {
 			return e
 		}
 
-		if c.ctx.Err() != nil {
-			return c.ctx.Err()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	return err
+}
+
+// requestContext returns the context for the request combining client context
+// with the request context and timeout.
+func (c client) requestContext(req *Request) (context.Context, context.CancelFunc) {
+	ctx, cancel := c.ctx, context.CancelFunc(func() {})
+
+	if req.ctx != nil && req.ctx != c.ctx {
+		rctx, rcancel := context.WithCancelCause(req.ctx)
+		stop := context.AfterFunc(c.ctx, func() {
+			rcancel(context.Cause(c.ctx))
+		})
+
+		ctx, cancel = rctx, func() {
+			stop()
+			rcancel(nil)
+		}
+	}
+
+	if req.timeout > 0 {
+		tctx, tcancel := context.WithTimeout(ctx, req.timeout)
+		parent := cancel
+
+		ctx, cancel = tctx, func() {
+			tcancel()
+			parent()
+		}
+	}
+
+	return ctx, cancel
+}
+
+// do sends the request retrying it according to the client retry policy.
+func (c client) do(ctx context.Context, req *Request, resp *Response) error {
+	// Body stream is consumed by the first attempt and can not be sent again.
+	stream := req.IsBodyStream()
+
+	for attempt := 1; ; attempt++ {
+		err := c.balance(ctx, req, resp, attempt)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var circuitErr CircuitOpenError
+		if errors.As(err, &circuitErr) {
+			return err
+		}
+
+		if c.Retry == nil || stream {
+			return err
+		}
+
+		delay, ok := c.Retry.shouldRetry(req, resp, err, attempt)
+		if !ok {
+			return err
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+
+		resetResponse(resp)
+	}
+}
+
+// resetResponse resets the response before sending the request again keeping
+// the response body streaming mode. Body stream of the previous response is
+// read to the end so that its connection can be reused.
+func resetResponse(resp *Response) {
+	stream := resp.StreamBody
+
+	if resp.IsBodyStream() {
+		resp.Body()
+	}
+
+	resp.Reset()
+	resp.releaseSlot()
+	resp.StreamBody = stream
+}
+
+// attempt sends the request once guarded by the circuit breaker if it is configured.
+func (c client) attempt(ctx context.Context, req *Request, resp *Response, attempt int) error {
+	var (
+		key  string
+		item *circuitTicket
+	)
+
+	release, err := c.queue(ctx, req, attempt)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if release != nil {
+			release()
+		}
+	}()
+
+	if c.circuits != nil {
+		key = c.circuitKey(req)
+
+		var (
+			tr  *circuitTransition
+			err error
+		)
+
+		item, tr, err = c.circuits.allow(key)
+		c.circuitChanged(ctx, key, tr)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	finish := c.Instrumenter.Observe(ctx, InstrumentationRequest, req, resp, attempt)
+	start := time.Now()
+
+	err = c.send(ctx, req, resp)
+
+	if resp.IsBodyStream() {
+		// Streamed response body is still being read from the connection so the
+		// bulkhead slot is held until the body stream is closed or released.
+		resp.release, release = release, nil
+	}
+
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	finish(err)
+
+	if c.requestLog != nil {
+		c.requestLog.log(c.Logger, c.name, req, resp, attempt, time.Since(start), err)
+	}
+
+	if item != nil {
+		if errors.Is(err, context.Canceled) {
+			// Canceled request tells nothing about the upstream health.
+			c.circuits.release(item)
+		} else {
+			c.circuitChanged(ctx, key, c.circuits.done(item, c.circuits.config.IsFailure(resp, err)))
 		}
 	}
 
 	return err
 }
 
+// send sends the request aborting it when context is canceled or its deadline is exceeded.
+func (c client) send(ctx context.Context, req *Request, resp *Response) error {
+	deadline, ok := ctx.Deadline()
+	ctxDeadline := ok
+
+	if c.Timeouts.Request > 0 {
+		if d := time.Now().Add(c.Timeouts.Request); !ok || d.Before(deadline) {
+			deadline, ok, ctxDeadline = d, true, false
+		}
+	}
+
+	var err error
+
+	hc := c.c
+	if resp.StreamBody {
+		hc = c.s
+	}
+
+	if ctx.Done() != nil {
+		// Connection is closed when the context is canceled, also while
+		// the streamed response body is being read.
+		defer c.abort.register(ctx, req.Request, deadline)()
+	}
+
+	if ok {
+		err = hc.DoDeadline(req.Request, resp.Response, deadline)
+	} else {
+		err = hc.Do(req.Request, resp.Response)
+	}
+
+	if ctxDeadline && errors.Is(err, fasthttp.ErrTimeout) {
+		return context.DeadlineExceeded
+	}
+
+	return err
+}
+
 // UserAgent returns client default user agent.
 func (c client) UserAgent() string {
 	return c.c.Name
@@ -216,8 +600,10 @@ func (c client) BaseURL() string {
 func (c client) WithContext(ctx context.Context) Client {
 	return &client{
 		clientOpts: c.clientOpts,
+		name:       c.name,
 		baseURL:    c.baseURL,
 		c:          c.c,
+		s:          c.s,
 		ctx:        ctx,
 	}
 }
@@ -226,71 +612,100 @@ func (c client) WithContext(ctx context.Context) Client {
 func (c client) WithBaseURL(url string) Client {
 	return &client{
 		clientOpts: c.clientOpts,
+		name:       c.name,
 		baseURL:    url,
 		c:          c.c,
+		s:          c.s,
 		ctx:        c.ctx,
 	}
 }
 
 // WithConfiguration returns a new client with specific named configuration.
+//
+// Named clients are created once and share their state (e.g. rate limits and tokens)
+// between calls.
 func (c client) WithConfiguration(name string) (Client, error) {
-	cl, ok := c.Configuration.Clients[name]
+	nc, ok := c.named.Load(name)
 	if !ok {
-		return nil, fmt.Errorf("client %q not found", name)
+		cl, ok := c.Configuration.Clients[name]
+		if !ok {
+			return nil, fmt.Errorf("client %q not found", name)
+		}
+
+		opt, err := cl.Options()
+		if err != nil {
+			return nil, fmt.Errorf("client %q: %w", name, err)
+		}
+
+		opts := c.options()
+		opts.ConfigurationName = name
+		opts.apply(opt)
+
+		if opts.tlsError != nil {
+			return nil, fmt.Errorf("client %q: %w", name, opts.tlsError)
+		}
+
+		nc, _ = c.named.LoadOrStore(name, newClient(opts))
 	}
 
-	return c.WithBaseURL(cl.BaseURL), nil
+	named, _ := nc.(*client)
+
+	return named.WithContext(c.ctx), nil
 }
 
 // WithOptions returns a new client with additional options applied.
 func (c client) WithOptions(opt ...Option) Client {
-	opts := &options{
-		RequestModifiers:  c.RequestMod,
-		ResponseModifiers: c.ResponseMod,
-		Configuration:     c.Configuration,
-		Context:           c.ctx,
-		Instrumenter:      c.Instrumenter,
-		TLSConfig:         c.c.TLSConfig,
-		Dial:              c.c.Dial,
-		Transport:         c.c.Transport,
-		RetryIf:           c.c.RetryIfErr,
-		UserAgent:         c.c.Name,
-		BaseURL:           c.baseURL,
-	}
+	opts := c.options()
 	opts.apply(opt)
 
-	if opts.UserAgent == "" {
-		opts.UserAgent = defaultUserAgent
-	}
-
-	retryIfErr := opts.RetryIf
-	if retryIfErr == nil {
-		retryIfErr = defaultRetryIfErr
-	}
+	return newClient(opts)
+}
 
-	return &client{
-		clientOpts: &clientOpts{
-			RequestMod:    opts.RequestModifiers,
-			ResponseMod:   opts.ResponseModifiers,
-			Configuration: opts.Configuration,
-			Instrumenter:  opts.Instrumenter,
-		},
-		c: &fasthttp.Client{
-			Name:               opts.UserAgent,
-			TLSConfig:          opts.TLSConfig,
-			Dial:               opts.Dial,
-			Transport:          opts.Transport,
-			RetryIfErr:         retryIfErr,
-			StreamResponseBody: opts.StreamResponse,
-		},
-		baseURL: opts.BaseURL,
-		ctx:     opts.Context,
+// options returns the current client options.
+func (c client) options() *options {
+	return &options{
+		RequestModifiers:   slices.Clone(c.RequestMod),
+		ResponseModifiers:  slices.Clone(c.ResponseMod),
+		Configuration:      c.Configuration,
+		Context:            c.ctx,
+		Instrumenter:       c.Instrumenter,
+		TLSConfig:          c.c.TLSConfig,
+		Dial:               c.Dial,
+		Transport:          c.abort.next,
+		RetryIf:            c.c.RetryIfErr,
+		UserAgent:          c.c.Name,
+		BaseURL:            c.baseURL,
+		StreamResponse:     c.c.StreamResponseBody,
+		Retry:              c.Retry,
+		Logger:             c.Logger,
+		ConfigurationName:  c.name,
+		Timeouts:           c.Timeouts,
+		Proxy:              c.Proxy,
+		RateLimit:          c.RateLimit,
+		OAuth2:             c.OAuth2,
+		ErrorDecoders:      slices.Clone(c.ErrorDecoders),
+		NamedErrorDecoders: c.NamedErrorDecoders,
+		RequestLogging:     c.RequestLogging,
+		NoTracePropagation: c.NoTracePropagation,
+		Signers:            slices.Clone(c.Signers),
+		Bulkhead:           c.Bulkhead,
+		LoadBalancer:       c.LoadBalancer,
+		Cache:              c.Cache,
+		ResponseCache:      c.ResponseCache,
+		circuits:           c.circuits,
+		limiter:            c.limiter,
+		bulkhead:           c.bulkhead,
+		balancer:           c.balancer,
+		responses:          c.responses,
+		tokens:             c.tokens,
+		tlsFiles:           c.tlsFiles,
+		tlsError:           c.tlsError,
 	}
 }
 
 // InstrRequest returns request and response if the operation is HTTP client request event.
 func InstrRequest(op string, args ...any) (*Request, *Response, bool) {
-	if op != InstrumentationRequest || len(args) != 2 {
+	if op != InstrumentationRequest || len(args) < 2 {
 		return nil, nil, false
 	}
 
@@ -300,6 +715,18 @@ func InstrRequest(op string, args ...any) (*Request, *Response, bool) {
 	return req, resp, ok1 && ok2
 }
 
+// InstrRequestQueue returns request and attempt number if the operation is HTTP client request queue event.
+func InstrRequestQueue(op string, args ...any) (*Request, int, bool) {
+	if op != InstrumentationRequestQueue || len(args) < 2 {
+		return nil, 0, false
+	}
+
+	req, ok1 := args[0].(*Request)
+	attempt, ok2 := args[1].(int)
+
+	return req, attempt, ok1 && ok2
+}
+
 func init() {
 	if di, ok := debug.ReadBuildInfo(); ok {
 		for _, dep := range di.Deps {
@@ -1,14 +1,20 @@
 package http
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"fmt"
 	"mime/multipart"
+	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"azugo.io/core/trace"
+
 	"github.com/go-quicktest/qt"
 	"github.com/valyala/fasthttp"
 )
@@ -536,3 +542,749 @@ func TestClientWithConfiguration(t *testing.T) {
 	qt.Assert(t, qt.IsNil(err))
 	qt.Check(t, qt.Equals(string(body), ""))
 }
+
+func TestClientRetry(t *testing.T) {
+	var calls atomic.Int32
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		if calls.Add(1) < 3 {
+			ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+			return
+		}
+
+		ctx.SetBodyString("Hello World")
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	attempts := make([]int, 0, 3)
+
+	c := NewClient(s.DialContext(), Retry{Backoff: time.Millisecond}, Instrumenter(func(ctx context.Context, op string, args ...any) func(err error) {
+		if attempt, ok := InstrRequestAttempt(op, args...); ok {
+			attempts = append(attempts, attempt)
+		}
+
+		_, _, ok := InstrRequest(op, args...)
+		qt.Check(t, qt.IsTrue(ok))
+
+		return func(err error) {}
+	}))
+
+	body, err := c.Get("http://localhost:8080")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), "Hello World"))
+	qt.Check(t, qt.DeepEquals(attempts, []int{1, 2, 3}))
+}
+
+func TestClientRetryExhausted(t *testing.T) {
+	var calls atomic.Int32
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		calls.Add(1)
+		ctx.SetStatusCode(fasthttp.StatusBadGateway)
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), Retry{MaxAttempts: 2, Backoff: time.Millisecond})
+
+	_, err := c.Get("http://localhost:8080")
+	qt.Check(t, qt.IsNotNil(err))
+	qt.Check(t, qt.Equals(calls.Load(), int32(2)))
+
+	calls.Store(0)
+
+	_, err = c.Post("http://localhost:8080", []byte("test"))
+	qt.Check(t, qt.IsNotNil(err))
+	qt.Check(t, qt.Equals(calls.Load(), int32(1)))
+
+	calls.Store(0)
+
+	// Request body stream is consumed by the first attempt.
+	_, err = c.Upload("http://localhost:8080", strings.NewReader("test"), 4)
+	qt.Check(t, qt.IsNotNil(err))
+	qt.Check(t, qt.Equals(calls.Load(), int32(1)))
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		if calls.Add(1) == 1 {
+			ctx.Response.Header.Set(fasthttp.HeaderRetryAfter, string(ctx.QueryArgs().Peek("after")))
+			ctx.SetStatusCode(fasthttp.StatusTooManyRequests)
+			return
+		}
+
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), Retry{Backoff: time.Millisecond, MaxBackoff: 2 * time.Second})
+
+	start := time.Now()
+	_, err := c.Get("http://localhost:8080", WithQueryArg("after", "1"))
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.IsTrue(time.Since(start) >= time.Second))
+	qt.Check(t, qt.Equals(calls.Load(), int32(2)))
+
+	calls.Store(0)
+
+	// Retry-After exceeding maximum backoff is not retried.
+	_, err = c.Get("http://localhost:8080", WithQueryArg("after", "60"))
+	qt.Check(t, qt.IsNotNil(err))
+	qt.Check(t, qt.Equals(calls.Load(), int32(1)))
+}
+
+func TestClientRetryContextCanceled(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+	}
+	s.Start()
+	defer s.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	c := NewClient(s.DialContext(), Context(ctx), Retry{MaxAttempts: 10, Backoff: time.Second})
+
+	start := time.Now()
+	_, err := c.Get("http://localhost:8080")
+	qt.Check(t, qt.ErrorIs(err, context.DeadlineExceeded))
+	qt.Check(t, qt.IsTrue(time.Since(start) < time.Second))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	d, ok := parseRetryAfter([]byte("120"), now)
+	qt.Check(t, qt.IsTrue(ok))
+	qt.Check(t, qt.Equals(d, 2*time.Minute))
+
+	d, ok = parseRetryAfter([]byte("Mon, 01 Jan 2024 12:00:30 GMT"), now)
+	qt.Check(t, qt.IsTrue(ok))
+	qt.Check(t, qt.Equals(d, 30*time.Second))
+
+	_, ok = parseRetryAfter([]byte("invalid"), now)
+	qt.Check(t, qt.IsFalse(ok))
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	var (
+		calls  atomic.Int32
+		failed atomic.Bool
+	)
+
+	failed.Store(true)
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		calls.Add(1)
+
+		if failed.Load() {
+			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+			return
+		}
+
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	states := make([]CircuitState, 0, 3)
+
+	c := NewClient(s.DialContext(), CircuitBreaker{
+		FailureThreshold: 2,
+		OpenTimeout:      100 * time.Millisecond,
+	}, Instrumenter(func(ctx context.Context, op string, args ...any) func(err error) {
+		if key, _, to, ok := InstrCircuitState(op, args...); ok {
+			qt.Check(t, qt.Equals(key, "localhost:8080"))
+
+			states = append(states, to)
+		}
+
+		return func(err error) {}
+	}))
+
+	for range 2 {
+		_, err := c.Get("http://localhost:8080")
+		qt.Check(t, qt.IsNotNil(err))
+	}
+
+	_, err := c.Get("http://localhost:8080")
+	qt.Check(t, qt.ErrorAs(err, new(CircuitOpenError)))
+	qt.Check(t, qt.Equals(calls.Load(), int32(2)))
+
+	time.Sleep(150 * time.Millisecond)
+
+	failed.Store(false)
+
+	_, err = c.Get("http://localhost:8080")
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(calls.Load(), int32(3)))
+	qt.Check(t, qt.DeepEquals(states, []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}))
+}
+
+func TestClientCircuitBreakerPerClient(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		if strings.HasPrefix(string(ctx.Path()), "/fail") {
+			ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+			return
+		}
+
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	cfg := &Configuration{
+		Clients: map[string]NamedClient{
+			"first": {
+				BaseURL: "http://localhost:8080/fail",
+			},
+			"second": {
+				BaseURL: "http://localhost:8080",
+			},
+		},
+	}
+
+	c := NewClient(s.DialContext(), cfg, CircuitBreaker{Scope: CircuitPerClient, FailureThreshold: 1})
+
+	first, err := c.WithConfiguration("first")
+	qt.Assert(t, qt.IsNil(err))
+
+	second, err := c.WithConfiguration("second")
+	qt.Assert(t, qt.IsNil(err))
+
+	_, err = first.Get("/")
+	qt.Check(t, qt.IsNotNil(err))
+
+	_, err = first.Get("/")
+	qt.Check(t, qt.ErrorAs(err, new(CircuitOpenError)))
+
+	_, err = second.Get("/")
+	qt.Check(t, qt.IsNil(err))
+}
+
+func TestCircuitBreakerCanceledTrial(t *testing.T) {
+	now := time.Now()
+
+	c := newCircuits(&CircuitBreaker{FailureThreshold: 1})
+	c.now = func() time.Time { return now }
+
+	item, _, err := c.allow("host")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.DeepEquals(c.done(item, true), &circuitTransition{From: CircuitClosed, To: CircuitOpen}))
+
+	now = now.Add(defaultCircuitOpenTimeout)
+
+	item, _, err = c.allow("host")
+	qt.Assert(t, qt.IsNil(err))
+
+	_, _, err = c.allow("host")
+	qt.Check(t, qt.ErrorAs(err, new(CircuitOpenError)))
+
+	// Canceled trial frees the slot and keeps the circuit half-open.
+	c.release(item)
+	qt.Check(t, qt.Equals(c.state("host"), CircuitHalfOpen))
+
+	item, _, err = c.allow("host")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.DeepEquals(c.done(item, false), &circuitTransition{From: CircuitHalfOpen, To: CircuitClosed}))
+}
+
+func TestCircuitBreakerStaleResult(t *testing.T) {
+	now := time.Now()
+
+	c := newCircuits(&CircuitBreaker{FailureThreshold: 1})
+	c.now = func() time.Time { return now }
+
+	// Request allowed while the circuit is closed is still in flight when the circuit opens.
+	stale, _, err := c.allow("host")
+	qt.Assert(t, qt.IsNil(err))
+
+	item, _, err := c.allow("host")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.DeepEquals(c.done(item, true), &circuitTransition{From: CircuitClosed, To: CircuitOpen}))
+
+	now = now.Add(defaultCircuitOpenTimeout)
+
+	trial, _, err := c.allow("host")
+	qt.Assert(t, qt.IsNil(err))
+
+	// Result of the request allowed before the circuit opened neither closes
+	// the circuit nor frees the trial slot.
+	qt.Check(t, qt.IsNil(c.done(stale, false)))
+	qt.Check(t, qt.Equals(c.state("host"), CircuitHalfOpen))
+
+	c.release(stale)
+
+	_, _, err = c.allow("host")
+	qt.Check(t, qt.ErrorAs(err, new(CircuitOpenError)))
+
+	qt.Check(t, qt.DeepEquals(c.done(trial, false), &circuitTransition{From: CircuitHalfOpen, To: CircuitClosed}))
+}
+
+func TestClientWithNamedConfiguration(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.UserAgent()) != "Test/1.0" {
+			ctx.SetStatusCode(fasthttp.StatusTeapot)
+			return
+		}
+
+		if string(ctx.Request.Header.Peek("X-Api-Version")) != "2" {
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			return
+		}
+
+		if string(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization)) != "Basic dXNlcjpwYXNz" {
+			ctx.SetStatusCode(fasthttp.StatusUnauthorized)
+			return
+		}
+
+		ctx.SetBodyString(string(ctx.Path()))
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	cfg := &Configuration{
+		Clients: map[string]NamedClient{
+			"test": {
+				BaseURL:   "http://localhost:8080/api",
+				UserAgent: "Test/1.0",
+				Timeout:   time.Second,
+				Headers: map[string]string{
+					"X-Api-Version": "2",
+				},
+				Auth: NamedClientAuth{
+					Type:     "basic",
+					Username: "user",
+					Password: "pass",
+				},
+				Retry: NamedClientRetry{
+					MaxAttempts: 3,
+				},
+			},
+		},
+	}
+
+	c, err := NewClient(s.DialContext(), cfg).WithConfiguration("test")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(c.UserAgent(), "Test/1.0"))
+
+	body, err := c.Get("/users")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), "/api/users"))
+}
+
+func TestClientWithNamedConfigurationShared(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	cfg := &Configuration{
+		Clients: map[string]NamedClient{
+			"test": {
+				BaseURL:   "http://localhost:8080",
+				RateLimit: NamedClientRateLimit{Rate: 20, Burst: 1},
+			},
+		},
+	}
+
+	c := NewClient(s.DialContext(), cfg)
+
+	start := time.Now()
+
+	for range 3 {
+		named, err := c.WithConfiguration("test")
+		qt.Assert(t, qt.IsNil(err))
+
+		_, err = named.Get("/")
+		qt.Assert(t, qt.IsNil(err))
+	}
+
+	// Rate limit is shared between calls to the same named client.
+	qt.Check(t, qt.IsTrue(time.Since(start) >= 90*time.Millisecond))
+}
+
+func TestClientRequestTimeout(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		time.Sleep(200 * time.Millisecond)
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), Timeouts{Request: 50 * time.Millisecond})
+
+	_, err := c.Get("http://localhost:8080")
+	qt.Check(t, qt.ErrorIs(err, fasthttp.ErrTimeout))
+}
+
+func TestRequestWithTimeout(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		time.Sleep(500 * time.Millisecond)
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	start := time.Now()
+
+	_, err := c.Get("http://localhost:8080", WithTimeout(50*time.Millisecond))
+	qt.Check(t, qt.ErrorIs(err, context.DeadlineExceeded))
+	qt.Check(t, qt.IsTrue(time.Since(start) < 400*time.Millisecond))
+}
+
+func TestRequestWithContextCanceled(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		time.Sleep(500 * time.Millisecond)
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+
+	_, err := c.Get("http://localhost:8080", WithContext(ctx))
+	qt.Check(t, qt.ErrorIs(err, context.Canceled))
+	qt.Check(t, qt.IsTrue(time.Since(start) < 400*time.Millisecond))
+}
+
+func TestRequestWithClientContextCanceled(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		time.Sleep(500 * time.Millisecond)
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	cctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	c := NewClient(s.DialContext()).WithContext(cctx)
+
+	start := time.Now()
+
+	_, err := c.Get("http://localhost:8080", WithContext(context.Background()))
+	qt.Check(t, qt.ErrorIs(err, context.Canceled))
+	qt.Check(t, qt.IsTrue(time.Since(start) < 400*time.Millisecond))
+}
+
+type testClosedConn struct {
+	net.Conn
+
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (c *testClosedConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+
+	return c.Conn.Close()
+}
+
+func TestClientContextCanceledClosesConnection(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		time.Sleep(500 * time.Millisecond)
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	conn := &testClosedConn{closed: make(chan struct{})}
+
+	dial := s.DialContext()
+
+	cctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	c := NewClient(DialContextFunc(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var err error
+		conn.Conn, err = dial(ctx, network, addr)
+
+		return conn, err
+	})).WithContext(cctx)
+
+	start := time.Now()
+
+	_, err := c.Get("http://localhost:8080")
+	qt.Check(t, qt.ErrorIs(err, context.Canceled))
+	qt.Check(t, qt.IsTrue(time.Since(start) < 400*time.Millisecond))
+
+	// Aborted request does not wait for the response.
+	select {
+	case <-conn.closed:
+	case <-time.After(200 * time.Millisecond):
+		t.Error("connection was not closed")
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), RateLimit{Rate: 20, Burst: 1})
+
+	start := time.Now()
+
+	for range 3 {
+		_, err := c.Get("http://localhost:8080")
+		qt.Assert(t, qt.IsNil(err))
+	}
+
+	qt.Check(t, qt.IsTrue(time.Since(start) >= 90*time.Millisecond))
+}
+
+func TestClientRateLimitFailFast(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), RateLimit{Rate: 1, Burst: 1, FailFast: true})
+
+	_, err := c.Get("http://localhost:8080")
+	qt.Assert(t, qt.IsNil(err))
+
+	_, err = c.Get("http://localhost:8080")
+
+	var rerr RateLimitExceededError
+
+	qt.Assert(t, qt.ErrorAs(err, &rerr))
+	qt.Check(t, qt.IsTrue(rerr.RetryAfter > 0))
+}
+
+func TestClientBulkhead(t *testing.T) {
+	var (
+		inFlight atomic.Int32
+		maxSeen  atomic.Int32
+	)
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			m := maxSeen.Load()
+			if n <= m || maxSeen.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+	s.Start()
+	defer s.Stop()
+
+	var queued atomic.Int32
+
+	c := NewClient(s.DialContext(), Bulkhead{MaxConcurrent: 2}, Instrumenter(func(_ context.Context, op string, args ...any) func(error) {
+		if _, _, ok := InstrRequestQueue(op, args...); ok {
+			queued.Add(1)
+		}
+
+		return func(error) {}
+	}))
+
+	done := make(chan error, 6)
+	for range 6 {
+		go func() {
+			_, err := c.Get("http://localhost:8080")
+			done <- err
+		}()
+	}
+
+	for range 6 {
+		qt.Check(t, qt.IsNil(<-done))
+	}
+
+	qt.Check(t, qt.Equals(maxSeen.Load(), int32(2)))
+	qt.Check(t, qt.Equals(queued.Load(), int32(6)))
+}
+
+func TestClientBulkheadFailFast(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		close(started)
+		<-release
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), Bulkhead{MaxConcurrent: 1, FailFast: true})
+
+	done := make(chan error)
+
+	go func() {
+		_, err := c.Get("http://localhost:8080")
+		done <- err
+	}()
+
+	<-started
+
+	_, err := c.Get("http://localhost:8080")
+
+	var berr BulkheadFullError
+
+	qt.Assert(t, qt.ErrorAs(err, &berr))
+	qt.Check(t, qt.Equals(berr.MaxConcurrent, 1))
+
+	close(release)
+	qt.Check(t, qt.IsNil(<-done))
+}
+
+func TestClientBulkheadContextCanceled(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		close(started)
+		<-release
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), Bulkhead{MaxConcurrent: 1})
+
+	done := make(chan error)
+
+	go func() {
+		_, err := c.Get("http://localhost:8080")
+		done <- err
+	}()
+
+	<-started
+
+	_, err := c.Get("http://localhost:8080", WithTimeout(50*time.Millisecond))
+	qt.Check(t, qt.ErrorIs(err, context.DeadlineExceeded))
+
+	close(release)
+	qt.Check(t, qt.IsNil(<-done))
+}
+
+func TestClientBulkheadStreamResponse(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.Path()) != "/stream" {
+			return
+		}
+
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			_, _ = w.WriteString("data")
+			_ = w.Flush()
+
+			<-done
+		})
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), Bulkhead{MaxConcurrent: 1, FailFast: true})
+
+	req := c.NewRequest()
+	defer c.ReleaseRequest(req)
+
+	req.SetRequestURI("http://localhost:8080/stream")
+
+	resp := c.NewResponse()
+	resp.StreamBody = true
+
+	qt.Assert(t, qt.IsNil(c.Do(req, resp)))
+	qt.Assert(t, qt.IsTrue(resp.IsBodyStream()))
+
+	// Slot is held while the response body is streamed.
+	_, err := c.Get("http://localhost:8080")
+	qt.Check(t, qt.ErrorAs(err, new(BulkheadFullError)))
+
+	qt.Check(t, qt.IsNil(resp.CloseBodyStream()))
+
+	_, err = c.Get("http://localhost:8080")
+	qt.Check(t, qt.IsNil(err))
+
+	c.ReleaseResponse(resp)
+}
+
+func TestClientTracePropagation(t *testing.T) {
+	var traceParent, traceState, requestID string
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		traceParent = string(ctx.Request.Header.Peek(trace.HeaderTraceParent))
+		traceState = string(ctx.Request.Header.Peek(trace.HeaderTraceState))
+		requestID = string(ctx.Request.Header.Peek(trace.HeaderRequestID))
+	}
+	s.Start()
+	defer s.Stop()
+
+	parent, err := trace.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	qt.Assert(t, qt.IsNil(err))
+
+	parent.TraceState = "congo=t61rcWkgMzE"
+
+	ctx := trace.ContextWithRequestID(trace.ContextWithSpan(context.Background(), parent), "req-1")
+
+	var span trace.SpanContext
+
+	c := NewClient(s.DialContext(), Instrumenter(func(ctx context.Context, op string, _ ...any) func(error) {
+		if op == InstrumentationRequest {
+			span, _ = trace.SpanFromContext(ctx)
+		}
+
+		return func(error) {}
+	}))
+
+	_, err = c.Get("http://localhost:8080", WithContext(ctx))
+	qt.Assert(t, qt.IsNil(err))
+
+	sc, err := trace.ParseTraceParent(traceParent)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(sc.TraceID, parent.TraceID))
+	qt.Check(t, qt.Not(qt.Equals(sc.SpanID, parent.SpanID)))
+	qt.Check(t, qt.Equals(span.SpanID, sc.SpanID))
+	qt.Check(t, qt.Equals(traceState, "congo=t61rcWkgMzE"))
+	qt.Check(t, qt.Equals(requestID, "req-1"))
+
+	_, err = c.WithOptions(TracePropagation(false)).Get("http://localhost:8080")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(traceParent, ""))
+	qt.Check(t, qt.Equals(requestID, ""))
+}
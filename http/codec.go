@@ -0,0 +1,408 @@
+package http
+
+import (
+	"encoding"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.yaml.in/yaml/v3"
+)
+
+// Codec encodes request bodies and decodes response bodies.
+type Codec interface {
+	// ContentType returns the media type used for Content-Type and Accept headers.
+	ContentType() string
+	// Marshal encodes v.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes data into v.
+	Unmarshal(data []byte, v any) error
+}
+
+// Built-in codecs.
+var (
+	// CodecJSON encodes and decodes JSON.
+	CodecJSON Codec = jsonCodec{}
+	// CodecXML encodes and decodes XML.
+	CodecXML Codec = xmlCodec{}
+	// CodecYAML encodes and decodes YAML.
+	CodecYAML Codec = yamlCodec{}
+	// CodecForm encodes and decodes URL encoded form.
+	//
+	// Supported values are url.Values, map[string][]string, map[string]string
+	// and structs with fields tagged with `form:"name"`.
+	CodecForm Codec = formCodec{}
+	// CodecMsgPack encodes and decodes MessagePack.
+	CodecMsgPack Codec = msgpackCodec{}
+)
+
+var (
+	codecsLock sync.RWMutex
+	codecs     = map[string]Codec{
+		"application/json":                  CodecJSON,
+		"text/json":                         CodecJSON,
+		"application/xml":                   CodecXML,
+		"text/xml":                          CodecXML,
+		"application/yaml":                  CodecYAML,
+		"application/x-yaml":                CodecYAML,
+		"text/yaml":                         CodecYAML,
+		"application/x-www-form-urlencoded": CodecForm,
+		"application/msgpack":               CodecMsgPack,
+		"application/x-msgpack":             CodecMsgPack,
+		"application/vnd.msgpack":           CodecMsgPack,
+	}
+)
+
+// RegisterCodec registers codec for the media type. Registering codec for
+// already registered media type replaces the previous one.
+func RegisterCodec(mediaType string, codec Codec) {
+	codecsLock.Lock()
+	defer codecsLock.Unlock()
+
+	codecs[mediaType] = codec
+}
+
+// CodecFor returns codec registered for the content type.
+//
+// Content type parameters are ignored. Structured syntax suffixes are supported,
+// for example application/problem+json is decoded with JSON codec.
+func CodecFor(contentType string) (Codec, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+
+	if c, ok := codecs[mediaType]; ok {
+		return c, true
+	}
+
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		c, ok := codecs["application/"+mediaType[i+1:]]
+
+		return c, ok
+	}
+
+	return nil, false
+}
+
+// Decode decodes the response body into v using the codec registered for the response content type.
+//
+// If there is no codec registered for the response content type, JSON codec is used.
+func (r Response) Decode(v any) error {
+	return decodeResponse(&r, CodecJSON, v)
+}
+
+// decodeResponse decodes the response body into v using the codec for the response content type
+// or fallback codec if there is no codec registered for it.
+func decodeResponse(resp *Response, fallback Codec, v any) error {
+	body, err := resp.BodyUncompressed()
+	if err != nil {
+		return err
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	if codec, ok := CodecFor(string(resp.Header.ContentType())); ok {
+		return codec.Unmarshal(body, v)
+	}
+
+	return fallback.Unmarshal(body, v)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string {
+	return "application/xml"
+}
+
+func (xmlCodec) Marshal(v any) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (xmlCodec) Unmarshal(data []byte, v any) error {
+	return xml.Unmarshal(data, v)
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) ContentType() string {
+	return "application/yaml"
+}
+
+func (yamlCodec) Marshal(v any) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+func (yamlCodec) Unmarshal(data []byte, v any) error {
+	return yaml.Unmarshal(data, v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type formCodec struct{}
+
+func (formCodec) ContentType() string {
+	return "application/x-www-form-urlencoded"
+}
+
+func (formCodec) Marshal(v any) ([]byte, error) {
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+
+	switch vv := v.(type) {
+	case url.Values:
+		return []byte(vv.Encode()), nil
+	case map[string][]string:
+		return []byte(url.Values(vv).Encode()), nil
+	case map[string]string:
+		for k, val := range vv {
+			args.Add(k, val)
+		}
+
+		return args.AppendBytes(nil), nil
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("form: unsupported type %T", v)
+	}
+
+	for i := range rv.NumField() {
+		name, omitEmpty, ok := formField(rv.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		f := rv.Field(i)
+		if omitEmpty && f.IsZero() {
+			continue
+		}
+
+		if f.Kind() == reflect.Slice && f.Type().Elem().Kind() != reflect.Uint8 {
+			for j := range f.Len() {
+				s, err := formatFormValue(f.Index(j))
+				if err != nil {
+					return nil, err
+				}
+
+				args.Add(name, s)
+			}
+
+			continue
+		}
+
+		s, err := formatFormValue(f)
+		if err != nil {
+			return nil, err
+		}
+
+		args.Add(name, s)
+	}
+
+	return args.AppendBytes(nil), nil
+}
+
+func (formCodec) Unmarshal(data []byte, v any) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	switch vv := v.(type) {
+	case *url.Values:
+		*vv = values
+
+		return nil
+	case *map[string][]string:
+		*vv = values
+
+		return nil
+	case *map[string]string:
+		*vv = make(map[string]string, len(values))
+		for k := range values {
+			(*vv)[k] = values.Get(k)
+		}
+
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form: unsupported type %T", v)
+	}
+
+	rv = rv.Elem()
+
+	for i := range rv.NumField() {
+		name, _, ok := formField(rv.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		vals, ok := values[name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+
+		f := rv.Field(i)
+
+		if f.Kind() == reflect.Slice && f.Type().Elem().Kind() != reflect.Uint8 {
+			s := reflect.MakeSlice(f.Type(), len(vals), len(vals))
+			for j, val := range vals {
+				if err := parseFormValue(s.Index(j), val); err != nil {
+					return fmt.Errorf("form: field %q: %w", name, err)
+				}
+			}
+
+			f.Set(s)
+
+			continue
+		}
+
+		if err := parseFormValue(f, vals[0]); err != nil {
+			return fmt.Errorf("form: field %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// formField returns form field name for the struct field.
+func formField(f reflect.StructField) (string, bool, bool) {
+	if !f.IsExported() {
+		return "", false, false
+	}
+
+	tag := f.Tag.Get("form")
+	if tag == "-" {
+		return "", false, false
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+
+	return name, opts == "omitempty", true
+}
+
+var errUnsupportedFormValue = errors.New("unsupported value type")
+
+func formatFormValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+
+		return formatFormValue(v.Elem())
+	}
+
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	default:
+		return "", errUnsupportedFormValue
+	}
+}
+
+func parseFormValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return parseFormValue(v.Elem(), s)
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(f)
+	default:
+		return errUnsupportedFormValue
+	}
+
+	return nil
+}
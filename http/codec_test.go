@@ -0,0 +1,142 @@
+package http
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-quicktest/qt"
+	"github.com/valyala/fasthttp"
+)
+
+type testUser struct {
+	Name  string   `json:"name" xml:"name" yaml:"name" msgpack:"name" form:"name"`
+	Age   int      `json:"age" xml:"age" yaml:"age" msgpack:"age" form:"age,omitempty"`
+	Roles []string `json:"roles" xml:"roles" yaml:"roles" msgpack:"roles" form:"role"`
+}
+
+func TestCodecFor(t *testing.T) {
+	tests := []struct {
+		contentType string
+		codec       Codec
+	}{
+		{"application/json", CodecJSON},
+		{"application/json; charset=utf-8", CodecJSON},
+		{"Application/Problem+JSON", CodecJSON},
+		{"text/xml; charset=utf-8", CodecXML},
+		{"application/atom+xml", CodecXML},
+		{"application/x-yaml", CodecYAML},
+		{"application/x-www-form-urlencoded", CodecForm},
+		{"application/vnd.msgpack", CodecMsgPack},
+	}
+
+	for _, test := range tests {
+		codec, ok := CodecFor(test.contentType)
+		qt.Check(t, qt.IsTrue(ok), qt.Commentf("content type %q", test.contentType))
+		qt.Check(t, qt.Equals(codec, test.codec), qt.Commentf("content type %q", test.contentType))
+	}
+
+	_, ok := CodecFor("text/plain")
+	qt.Check(t, qt.IsFalse(ok))
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	user := testUser{Name: "John Doe", Age: 42, Roles: []string{"admin", "user"}}
+
+	for _, codec := range []Codec{CodecJSON, CodecXML, CodecYAML, CodecForm, CodecMsgPack} {
+		buf, err := codec.Marshal(user)
+		qt.Assert(t, qt.IsNil(err), qt.Commentf("codec %s", codec.ContentType()))
+
+		var v testUser
+		qt.Assert(t, qt.IsNil(codec.Unmarshal(buf, &v)), qt.Commentf("codec %s", codec.ContentType()))
+		qt.Check(t, qt.DeepEquals(v, user), qt.Commentf("codec %s", codec.ContentType()))
+	}
+}
+
+func TestFormCodec(t *testing.T) {
+	type form struct {
+		Name    string    `form:"name"`
+		Enabled bool      `form:"enabled"`
+		Score   *float64  `form:"score,omitempty"`
+		At      time.Time `form:"at"`
+		Skip    string    `form:"-"`
+	}
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	buf, err := CodecForm.Marshal(form{Name: "a b", Enabled: true, At: at, Skip: "x"})
+	qt.Assert(t, qt.IsNil(err))
+
+	values, err := url.ParseQuery(string(buf))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.DeepEquals(values, url.Values{
+		"name":    {"a b"},
+		"enabled": {"true"},
+		"at":      {"2024-05-01T12:00:00Z"},
+	}))
+
+	var v form
+	qt.Assert(t, qt.IsNil(CodecForm.Unmarshal([]byte("name=test&enabled=1&score=1.5&at=2024-05-01T12%3A00%3A00Z"), &v)))
+	qt.Check(t, qt.Equals(v.Name, "test"))
+	qt.Check(t, qt.IsTrue(v.Enabled))
+	qt.Assert(t, qt.IsNotNil(v.Score))
+	qt.Check(t, qt.Equals(*v.Score, 1.5))
+	qt.Check(t, qt.IsTrue(v.At.Equal(at)))
+
+	var m map[string]string
+	qt.Assert(t, qt.IsNil(CodecForm.Unmarshal([]byte("a=1&b=2"), &m)))
+	qt.Check(t, qt.DeepEquals(m, map[string]string{"a": "1", "b": "2"}))
+}
+
+func TestGetAs(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.Request.Header.Peek(fasthttp.HeaderAccept)) != "application/json" {
+			ctx.SetStatusCode(fasthttp.StatusNotAcceptable)
+			return
+		}
+
+		ctx.SetContentType("application/yaml")
+		ctx.SetBodyString("name: John Doe\nage: 42\n")
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	user, err := GetAs[testUser](c, "http://localhost:8080")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.DeepEquals(user, testUser{Name: "John Doe", Age: 42}))
+}
+
+func TestPostAs(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.Request.Header.ContentType()) != "application/msgpack" {
+			ctx.SetStatusCode(fasthttp.StatusUnsupportedMediaType)
+			return
+		}
+
+		var user testUser
+		if err := CodecMsgPack.Unmarshal(ctx.PostBody(), &user); err != nil {
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			return
+		}
+
+		user.Age++
+
+		buf, _ := CodecMsgPack.Marshal(user)
+
+		ctx.SetContentType("application/msgpack")
+		ctx.SetBody(buf)
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	user, err := PostAs[testUser, *testUser](c, "http://localhost:8080", testUser{Name: "John Doe", Age: 41}, WithCodec(CodecMsgPack))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.IsNotNil(user))
+	qt.Check(t, qt.Equals(user.Age, 42))
+}
@@ -1,12 +1,422 @@
 package http
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"azugo.io/core/cert"
 	"azugo.io/core/validation"
 )
 
+// NamedClientTLS represents the TLS configuration for the named client instance.
+type NamedClientTLS struct {
+	// CA is the path to PEM encoded CA certificates file used to verify server certificate.
+	CA string `mapstructure:"ca"`
+	// Cert is the path to PEM encoded client certificate file. File can also contain private key.
+	Cert string `mapstructure:"cert"`
+	// Key is the path to PEM encoded client private key file.
+	Key string `mapstructure:"key"`
+	// Password to decrypt the client private key.
+	Password string `mapstructure:"password"`
+	// ServerName is used to verify the server certificate hostname.
+	ServerName string `mapstructure:"server_name"`
+	// InsecureSkipVerify disables server certificate verification.
+	InsecureSkipVerify bool `mapstructure:"skip_verify"`
+	// ReloadInterval is the minimum interval between checks for certificate file changes.
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+}
+
+// NamedClientOAuth2 represents the OAuth2 client credentials configuration for the named client instance.
+type NamedClientOAuth2 struct {
+	TokenURL     string   `mapstructure:"token_url" validate:"omitempty,http_url"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	Scopes       []string `mapstructure:"scopes"`
+	Audience     string   `mapstructure:"audience"`
+	// Cert is the path to PEM encoded file with certificate and private key
+	// used for private_key_jwt client authentication method.
+	Cert string `mapstructure:"cert"`
+	// Password to decrypt the private key.
+	Password string `mapstructure:"password"`
+	KeyID    string `mapstructure:"key_id"`
+	// TLS configures TLS for token requests. If not set, the client TLS configuration is used.
+	TLS NamedClientTLS `mapstructure:"tls"`
+}
+
+// NamedClientAuth represents the authentication configuration for the named client instance.
+type NamedClientAuth struct {
+	Type     string            `mapstructure:"type" validate:"omitempty,oneof=basic bearer oauth2"`
+	Username string            `mapstructure:"username" validate:"required_if=Type basic"`
+	Password string            `mapstructure:"password"`
+	Token    string            `mapstructure:"token" validate:"required_if=Type bearer"`
+	OAuth2   NamedClientOAuth2 `mapstructure:"oauth2"`
+}
+
+// NamedClientHMAC represents the HMAC request signing configuration for the named client instance.
+type NamedClientHMAC struct {
+	KeyID           string   `mapstructure:"key_id"`
+	Secret          string   `mapstructure:"secret"`
+	Algorithm       string   `mapstructure:"algorithm" validate:"omitempty,oneof=sha1 sha256 sha512"`
+	Encoding        string   `mapstructure:"encoding" validate:"omitempty,oneof=hex base64"`
+	Components      []string `mapstructure:"components"`
+	Separator       string   `mapstructure:"separator"`
+	Header          string   `mapstructure:"header"`
+	TimestampHeader string   `mapstructure:"timestamp_header"`
+	Format          string   `mapstructure:"format"`
+}
+
+// NamedClientAWS represents the AWS Signature Version 4 request signing configuration for the named client instance.
+type NamedClientAWS struct {
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	SessionToken    string `mapstructure:"session_token"`
+	Region          string `mapstructure:"region"`
+	Service         string `mapstructure:"service"`
+	UnsignedPayload bool   `mapstructure:"unsigned_payload"`
+}
+
+// NamedClientSigning represents the request signing configuration for the named client instance.
+type NamedClientSigning struct {
+	Type string          `mapstructure:"type" validate:"omitempty,oneof=hmac aws_sigv4"`
+	HMAC NamedClientHMAC `mapstructure:"hmac"`
+	AWS  NamedClientAWS  `mapstructure:"aws"`
+}
+
+// NamedClientRetry represents the retry configuration for the named client instance.
+//
+// Retries are enabled if MaxAttempts is greater than one.
+type NamedClientRetry struct {
+	MaxAttempts int           `mapstructure:"max_attempts" validate:"omitempty,min=0"`
+	Backoff     time.Duration `mapstructure:"backoff" validate:"omitempty,min=0"`
+	MaxBackoff  time.Duration `mapstructure:"max_backoff" validate:"omitempty,min=0"`
+}
+
+// NamedClientRateLimit represents the rate limit configuration for the named client instance.
+//
+// Rate limit is enabled if Rate is greater than zero.
+type NamedClientRateLimit struct {
+	Rate     float64 `mapstructure:"rate" validate:"omitempty,min=0"`
+	Burst    int     `mapstructure:"burst" validate:"omitempty,min=0"`
+	FailFast bool    `mapstructure:"fail_fast"`
+}
+
+// NamedClientBulkhead represents the concurrency limit configuration for the named client instance.
+//
+// Bulkhead is enabled if MaxConcurrent is greater than zero.
+type NamedClientBulkhead struct {
+	MaxConcurrent int  `mapstructure:"max_concurrent" validate:"omitempty,min=0"`
+	FailFast      bool `mapstructure:"fail_fast"`
+}
+
+// NamedClientLogging represents the request logging configuration for the named client instance.
+type NamedClientLogging struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	Bodies        bool     `mapstructure:"bodies"`
+	MaxBodySize   int      `mapstructure:"max_body_size" validate:"omitempty,min=0"`
+	RedactHeaders []string `mapstructure:"redact_headers"`
+	RedactQuery   []string `mapstructure:"redact_query"`
+	RedactFields  []string `mapstructure:"redact_fields"`
+}
+
+// NamedClientLoadBalancing represents the load balancing configuration for the named client instance.
+//
+// Load balancing is enabled if multiple base URLs are configured, SRV record is set or Resolve is enabled.
+type NamedClientLoadBalancing struct {
+	Strategy         string        `mapstructure:"strategy" validate:"omitempty,oneof=round_robin random least_in_flight"`
+	Resolve          bool          `mapstructure:"resolve"`
+	SRV              string        `mapstructure:"srv"`
+	RefreshInterval  time.Duration `mapstructure:"refresh_interval" validate:"omitempty,min=0"`
+	FailureThreshold int           `mapstructure:"failure_threshold" validate:"omitempty,min=0"`
+	EjectTimeout     time.Duration `mapstructure:"eject_timeout" validate:"omitempty,min=0"`
+	NoFailover       bool          `mapstructure:"no_failover"`
+}
+
+func (c NamedClientLoadBalancing) option(urls []string) (Option, error) {
+	strategy, err := ParseBalanceStrategy(c.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadBalancer{
+		URLs:             urls,
+		Resolve:          c.Resolve,
+		SRV:              c.SRV,
+		RefreshInterval:  c.RefreshInterval,
+		Strategy:         strategy,
+		FailureThreshold: c.FailureThreshold,
+		EjectTimeout:     c.EjectTimeout,
+		NoFailover:       c.NoFailover,
+	}, nil
+}
+
+// NamedClientCache represents the response cache configuration for the named client instance.
+type NamedClientCache struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Private      bool          `mapstructure:"private"`
+	StaleIfError time.Duration `mapstructure:"stale_if_error" validate:"omitempty,min=0"`
+	KeepStale    time.Duration `mapstructure:"keep_stale" validate:"omitempty,min=0"`
+	MaxBodySize  int           `mapstructure:"max_body_size" validate:"omitempty,min=0"`
+}
+
 // NamedClient represents the configuration for the named client instance.
 type NamedClient struct {
-	BaseURL string `mapstructure:"base_url" validate:"required http_url"`
+	BaseURL       string                   `mapstructure:"base_url" validate:"required_without=BaseURLs,omitempty,http_url"`
+	BaseURLs      []string                 `mapstructure:"base_urls" validate:"omitempty,dive,http_url"`
+	UserAgent     string                   `mapstructure:"user_agent"`
+	Timeout       time.Duration            `mapstructure:"timeout" validate:"omitempty,min=0"`
+	DialTimeout   time.Duration            `mapstructure:"dial_timeout" validate:"omitempty,min=0"`
+	ReadTimeout   time.Duration            `mapstructure:"read_timeout" validate:"omitempty,min=0"`
+	WriteTimeout  time.Duration            `mapstructure:"write_timeout" validate:"omitempty,min=0"`
+	IdleTimeout   time.Duration            `mapstructure:"idle_timeout" validate:"omitempty,min=0"`
+	Headers       map[string]string        `mapstructure:"headers"`
+	TLS           NamedClientTLS           `mapstructure:"tls"`
+	Proxy         string                   `mapstructure:"proxy" validate:"omitempty,url"`
+	NoProxy       string                   `mapstructure:"no_proxy"`
+	ProxyFromEnv  bool                     `mapstructure:"proxy_from_env"`
+	Auth          NamedClientAuth          `mapstructure:"auth"`
+	Signing       NamedClientSigning       `mapstructure:"signing"`
+	Retry         NamedClientRetry         `mapstructure:"retry"`
+	RateLimit     NamedClientRateLimit     `mapstructure:"rate_limit"`
+	Bulkhead      NamedClientBulkhead      `mapstructure:"bulkhead"`
+	LoadBalancing NamedClientLoadBalancing `mapstructure:"load_balancing"`
+	Cache         NamedClientCache         `mapstructure:"cache"`
+	Logging       NamedClientLogging       `mapstructure:"logging"`
+}
+
+// Options returns the HTTP client options for the named client configuration.
+func (c NamedClient) Options() ([]Option, error) {
+	opts := []Option{
+		BaseURL(c.BaseURL),
+		Timeouts{
+			Request: c.Timeout,
+			Dial:    c.DialTimeout,
+			Read:    c.ReadTimeout,
+			Write:   c.WriteTimeout,
+			Idle:    c.IdleTimeout,
+		},
+	}
+
+	if len(c.BaseURLs) > 1 || c.LoadBalancing.Resolve || c.LoadBalancing.SRV != "" {
+		o, err := c.LoadBalancing.option(c.BaseURLs)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, o)
+	} else if c.BaseURL == "" && len(c.BaseURLs) == 1 {
+		opts[0] = BaseURL(c.BaseURLs[0])
+	}
+
+	if c.UserAgent != "" {
+		opts = append(opts, UserAgent(c.UserAgent))
+	}
+
+	if len(c.Headers) > 0 {
+		opts = append(opts, Headers(c.Headers))
+	}
+
+	tlsOption, err := c.TLS.option()
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsOption != nil {
+		opts = append(opts, tlsOption)
+	}
+
+	if c.Proxy != "" || c.ProxyFromEnv {
+		opts = append(opts, ProxyConfig{
+			HTTPProxy:       c.Proxy,
+			HTTPSProxy:      c.Proxy,
+			NoProxy:         c.NoProxy,
+			FromEnvironment: c.ProxyFromEnv,
+		})
+	}
+
+	switch c.Auth.Type {
+	case "basic":
+		opts = append(opts, BasicAuth{Username: c.Auth.Username, Password: c.Auth.Password})
+	case "bearer":
+		opts = append(opts, BearerToken(c.Auth.Token))
+	case "oauth2":
+		o, err := c.Auth.OAuth2.option()
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, o)
+	}
+
+	if c.Signing.Type != "" {
+		o, err := c.Signing.option()
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, o)
+	}
+
+	if c.Retry.MaxAttempts > 1 {
+		opts = append(opts, Retry{
+			MaxAttempts: c.Retry.MaxAttempts,
+			Backoff:     c.Retry.Backoff,
+			MaxBackoff:  c.Retry.MaxBackoff,
+		})
+	}
+
+	if c.RateLimit.Rate > 0 {
+		opts = append(opts, RateLimit{
+			Rate:     c.RateLimit.Rate,
+			Burst:    c.RateLimit.Burst,
+			FailFast: c.RateLimit.FailFast,
+		})
+	}
+
+	if c.Bulkhead.MaxConcurrent > 0 {
+		opts = append(opts, Bulkhead{
+			MaxConcurrent: c.Bulkhead.MaxConcurrent,
+			FailFast:      c.Bulkhead.FailFast,
+		})
+	}
+
+	if c.Cache.Enabled {
+		opts = append(opts, ResponseCache{
+			Private:      c.Cache.Private,
+			StaleIfError: c.Cache.StaleIfError,
+			KeepStale:    c.Cache.KeepStale,
+			MaxBodySize:  c.Cache.MaxBodySize,
+		})
+	}
+
+	if c.Logging.Enabled {
+		opts = append(opts, RequestLogging{
+			Bodies:        c.Logging.Bodies,
+			MaxBodySize:   c.Logging.MaxBodySize,
+			RedactHeaders: c.Logging.RedactHeaders,
+			RedactQuery:   c.Logging.RedactQuery,
+			RedactFields:  c.Logging.RedactFields,
+		})
+	}
+
+	return opts, nil
+}
+
+// mutualTLS returns TLS settings or nil if no TLS settings are configured.
+func (c NamedClientTLS) mutualTLS() *MutualTLS {
+	if c.CA == "" && c.Cert == "" && c.ServerName == "" && !c.InsecureSkipVerify {
+		return nil
+	}
+
+	return &MutualTLS{
+		CertFile:           c.Cert,
+		KeyFile:            c.Key,
+		Password:           []byte(c.Password),
+		CAFile:             c.CA,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		ReloadInterval:     c.ReloadInterval,
+	}
+}
+
+// config returns TLS configuration or nil if no TLS settings are configured.
+func (c NamedClientTLS) config() (*tls.Config, error) {
+	m := c.mutualTLS()
+	if m == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	return m.TLSConfig()
+}
+
+// option returns the client option with loaded certificate files or nil if no TLS settings are configured.
+func (c NamedClientTLS) option() (Option, error) {
+	m := c.mutualTLS()
+	if m == nil {
+		return nil, nil
+	}
+
+	files := newTLSFiles(m)
+	if err := files.reload(true); err != nil {
+		return nil, err
+	}
+
+	return tlsFilesOption{files}, nil
+}
+
+func (c NamedClientOAuth2) option() (OAuth2ClientCredentials, error) {
+	if c.TokenURL == "" || c.ClientID == "" {
+		return OAuth2ClientCredentials{}, errors.New("oauth2 token URL and client ID are required")
+	}
+
+	o := OAuth2ClientCredentials{
+		TokenURL:     c.TokenURL,
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Scopes:       c.Scopes,
+		Audience:     c.Audience,
+		KeyID:        c.KeyID,
+	}
+
+	if c.Cert != "" {
+		var opts []cert.Option
+		if c.Password != "" {
+			opts = append(opts, cert.Password(c.Password))
+		}
+
+		crt, err := cert.ParseTLSCertificateFromFile(c.Cert, opts...)
+		if err != nil {
+			return o, fmt.Errorf("failed to load oauth2 client certificate: %w", err)
+		}
+
+		o.Certificate = crt
+	}
+
+	tlsConfig, err := c.TLS.config()
+	if err != nil {
+		return o, fmt.Errorf("failed to load oauth2 TLS configuration: %w", err)
+	}
+
+	o.TLSConfig = tlsConfig
+
+	return o, nil
+}
+
+func (c NamedClientSigning) option() (Option, error) {
+	switch c.Type {
+	case "hmac":
+		if c.HMAC.Secret == "" {
+			return nil, errors.New("hmac signing secret is required")
+		}
+
+		return HMACSigner{
+			KeyID:           c.HMAC.KeyID,
+			Secret:          []byte(c.HMAC.Secret),
+			Algorithm:       c.HMAC.Algorithm,
+			Encoding:        c.HMAC.Encoding,
+			Components:      c.HMAC.Components,
+			Separator:       c.HMAC.Separator,
+			Header:          c.HMAC.Header,
+			TimestampHeader: c.HMAC.TimestampHeader,
+			Format:          c.HMAC.Format,
+		}, nil
+	case "aws_sigv4":
+		if c.AWS.AccessKeyID == "" || c.AWS.SecretAccessKey == "" || c.AWS.Region == "" || c.AWS.Service == "" {
+			return nil, errors.New("aws signing access key, secret key, region and service are required")
+		}
+
+		return AWSSigV4{
+			AccessKeyID:     c.AWS.AccessKeyID,
+			SecretAccessKey: c.AWS.SecretAccessKey,
+			SessionToken:    c.AWS.SessionToken,
+			Region:          c.AWS.Region,
+			Service:         c.AWS.Service,
+			UnsignedPayload: c.AWS.UnsignedPayload,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing type %q", c.Type)
+	}
 }
 
 // Configuration represents the configuration for the HTTP client.
@@ -19,11 +429,11 @@ func (c *Configuration) apply(o *options) {
 	o.Configuration = c
 }
 
-// Validate Metrics configuration section.
+// Validate HTTP client configuration section.
 func (c *Configuration) Validate(valid *validation.Validate) error {
-	for _, client := range c.Clients {
+	for name, client := range c.Clients {
 		if err := valid.Struct(client); err != nil {
-			return err
+			return fmt.Errorf("http client %q: %w", name, err)
 		}
 	}
 
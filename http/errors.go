@@ -1,8 +1,11 @@
 package http
 
 import (
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/goccy/go-json"
 	"github.com/valyala/fasthttp"
 )
 
@@ -46,10 +49,25 @@ type ResponseStatusCode interface {
 }
 
 // UnauthorizedError is an error that occurs when user is not authorized.
-type UnauthorizedError struct{}
+type UnauthorizedError struct {
+	// Err contains error details from the response body if available.
+	Err error
+}
+
+func (e UnauthorizedError) Error() string {
+	return statusError("unauthorized", e.Err)
+}
+
+// Unwrap returns the error details.
+func (e UnauthorizedError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports if the target is UnauthorizedError without error details.
+func (e UnauthorizedError) Is(target error) bool {
+	t, ok := target.(UnauthorizedError)
 
-func (UnauthorizedError) Error() string {
-	return "unauthorized"
+	return ok && t.Err == nil
 }
 
 // StatusCode returns the HTTP 401 Unauthorized status code.
@@ -58,10 +76,25 @@ func (UnauthorizedError) StatusCode() int {
 }
 
 // ForbiddenError is an error that occurs when user access is denied.
-type ForbiddenError struct{}
+type ForbiddenError struct {
+	// Err contains error details from the response body if available.
+	Err error
+}
+
+func (e ForbiddenError) Error() string {
+	return statusError("access forbidden", e.Err)
+}
+
+// Unwrap returns the error details.
+func (e ForbiddenError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports if the target is ForbiddenError without error details.
+func (e ForbiddenError) Is(target error) bool {
+	t, ok := target.(ForbiddenError)
 
-func (ForbiddenError) Error() string {
-	return "access forbidden"
+	return ok && t.Err == nil
 }
 
 // StatusCode returns the HTTP 403 Forbidden status code.
@@ -72,17 +105,231 @@ func (ForbiddenError) StatusCode() int {
 // NotFoundError is an error that occurs when searched resource is not found.
 type NotFoundError struct {
 	Resource string
+	// Err contains error details from the response body if available.
+	Err error
 }
 
 func (e NotFoundError) Error() string {
 	if e.Resource == "" {
-		return "resource not found"
+		return statusError("resource not found", e.Err)
 	}
 
-	return e.Resource + " not found"
+	return statusError(e.Resource+" not found", e.Err)
+}
+
+// Unwrap returns the error details.
+func (e NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports if the target is NotFoundError for the same resource without error details.
+func (e NotFoundError) Is(target error) bool {
+	t, ok := target.(NotFoundError)
+
+	return ok && t.Err == nil && t.Resource == e.Resource
 }
 
 // StatusCode returns the HTTP 404 Not Found status code.
 func (NotFoundError) StatusCode() int {
 	return fasthttp.StatusNotFound
 }
+
+// ProblemDetails represents RFC 9457 problem details error response.
+type ProblemDetails struct {
+	// Type is the URI reference that identifies the problem type.
+	Type string `json:"type,omitempty"`
+	// Title is the short human readable summary of the problem type.
+	Title string `json:"title,omitempty"`
+	// Status is the HTTP status code generated by the origin server.
+	Status int `json:"status,omitempty"`
+	// Detail is the human readable explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is the URI reference that identifies the specific occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+	// Extensions contains additional problem details members.
+	Extensions map[string]any `json:"-"`
+}
+
+type problemDetails ProblemDetails
+
+var problemDetailsMembers = []string{"type", "title", "status", "detail", "instance"}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*problemDetails)(p)); err != nil {
+		return err
+	}
+
+	ext := make(map[string]any)
+	if err := json.Unmarshal(data, &ext); err != nil {
+		return err
+	}
+
+	for _, k := range problemDetailsMembers {
+		delete(ext, k)
+	}
+
+	p.Extensions = nil
+	if len(ext) > 0 {
+		p.Extensions = ext
+	}
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	if len(p.Extensions) == 0 {
+		return json.Marshal(problemDetails(p))
+	}
+
+	buf, err := json.Marshal(problemDetails(p))
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]any, len(p.Extensions)+len(problemDetailsMembers))
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(m)
+}
+
+// Error implements error interface.
+func (p ProblemDetails) Error() string {
+	switch {
+	case p.Title != "" && p.Detail != "":
+		return p.Title + ": " + p.Detail
+	case p.Title != "":
+		return p.Title
+	case p.Detail != "":
+		return p.Detail
+	case p.Type != "" && p.Type != "about:blank":
+		return p.Type
+	default:
+		return "problem status " + strconv.Itoa(p.Status)
+	}
+}
+
+// StatusCode returns the problem details HTTP status code.
+func (p ProblemDetails) StatusCode() int {
+	return p.Status
+}
+
+// statusError formats error message with optional error details.
+func statusError(msg string, err error) string {
+	if err == nil {
+		return msg
+	}
+
+	return msg + ": " + err.Error()
+}
+
+// BadRequestError is an error that occurs when request is invalid.
+type BadRequestError struct {
+	// Err contains error details from the response body if available.
+	Err error
+}
+
+func (e BadRequestError) Error() string {
+	return statusError("bad request", e.Err)
+}
+
+// Unwrap returns the error details.
+func (e BadRequestError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the HTTP 400 Bad Request status code.
+func (BadRequestError) StatusCode() int {
+	return fasthttp.StatusBadRequest
+}
+
+// ConflictError is an error that occurs when request conflicts with the current state of the resource.
+type ConflictError struct {
+	// Err contains error details from the response body if available.
+	Err error
+}
+
+func (e ConflictError) Error() string {
+	return statusError("conflict", e.Err)
+}
+
+// Unwrap returns the error details.
+func (e ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the HTTP 409 Conflict status code.
+func (ConflictError) StatusCode() int {
+	return fasthttp.StatusConflict
+}
+
+// UnprocessableEntityError is an error that occurs when request content can not be processed.
+type UnprocessableEntityError struct {
+	// Err contains error details from the response body if available.
+	Err error
+}
+
+func (e UnprocessableEntityError) Error() string {
+	return statusError("unprocessable entity", e.Err)
+}
+
+// Unwrap returns the error details.
+func (e UnprocessableEntityError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the HTTP 422 Unprocessable Entity status code.
+func (UnprocessableEntityError) StatusCode() int {
+	return fasthttp.StatusUnprocessableEntity
+}
+
+// TooManyRequestsError is an error that occurs when too many requests have been sent.
+type TooManyRequestsError struct {
+	// RetryAfter is the delay requested by the server before the next request (zero if not set).
+	RetryAfter time.Duration
+	// Err contains error details from the response body if available.
+	Err error
+}
+
+func (e TooManyRequestsError) Error() string {
+	return statusError("too many requests", e.Err)
+}
+
+// Unwrap returns the error details.
+func (e TooManyRequestsError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the HTTP 429 Too Many Requests status code.
+func (TooManyRequestsError) StatusCode() int {
+	return fasthttp.StatusTooManyRequests
+}
+
+// ServerError is an error that occurs when server fails to process the request with 5xx status code.
+type ServerError struct {
+	// Status is the response HTTP status code.
+	Status int
+	// Err contains error details from the response body if available.
+	Err error
+}
+
+func (e ServerError) Error() string {
+	return statusError("server error "+strconv.Itoa(e.Status), e.Err)
+}
+
+// Unwrap returns the error details.
+func (e ServerError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the response HTTP status code.
+func (e ServerError) StatusCode() int {
+	return e.Status
+}
@@ -0,0 +1,174 @@
+package http
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-quicktest/qt"
+	"github.com/valyala/fasthttp"
+)
+
+func newTestErrorResponse(status int, contentType, body string) *Response {
+	resp := &Response{Response: fasthttp.AcquireResponse()}
+	resp.SetStatusCode(status)
+
+	if contentType != "" {
+		resp.Header.SetContentType(contentType)
+	}
+
+	resp.SetBodyString(body)
+
+	return resp
+}
+
+func TestResponseErrorProblemDetails(t *testing.T) {
+	resp := newTestErrorResponse(fasthttp.StatusBadRequest, "application/problem+json; charset=utf-8",
+		`{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.","detail":"Your current balance is 30, but that costs 50.","balance":30}`)
+	defer fasthttp.ReleaseResponse(resp.Response)
+
+	err := resp.Error()
+	qt.Check(t, qt.ErrorAs(err, new(BadRequestError)))
+
+	var p ProblemDetails
+	qt.Assert(t, qt.ErrorAs(err, &p))
+	qt.Check(t, qt.Equals(p.Type, "https://example.com/probs/out-of-credit"))
+	qt.Check(t, qt.Equals(p.Status, fasthttp.StatusBadRequest))
+	qt.Check(t, qt.Equals(p.Error(), "You do not have enough credit.: Your current balance is 30, but that costs 50."))
+	qt.Check(t, qt.DeepEquals(p.Extensions, map[string]any{"balance": float64(30)}))
+
+	var sc ResponseStatusCode
+	qt.Assert(t, qt.ErrorAs(err, &sc))
+	qt.Check(t, qt.Equals(sc.StatusCode(), fasthttp.StatusBadRequest))
+}
+
+func TestResponseErrorResponseWithCharset(t *testing.T) {
+	resp := newTestErrorResponse(fasthttp.StatusUnprocessableEntity, "application/json; charset=utf-8",
+		`{"errors":[{"type":"ValidationError","message":"name is required"}]}`)
+	defer fasthttp.ReleaseResponse(resp.Response)
+
+	// ErrorResponse is returned as is for compatibility.
+	e, ok := resp.Error().(ErrorResponse)
+	qt.Assert(t, qt.IsTrue(ok))
+	qt.Check(t, qt.Equals(e.Error(), "ValidationError: name is required"))
+}
+
+func TestResponseErrorDetails(t *testing.T) {
+	tests := []struct {
+		status int
+		target error
+		msg    string
+	}{
+		{fasthttp.StatusUnauthorized, UnauthorizedError{}, "unauthorized: Token expired"},
+		{fasthttp.StatusForbidden, ForbiddenError{}, "access forbidden: Token expired"},
+		{fasthttp.StatusNotFound, NotFoundError{}, "resource not found: Token expired"},
+	}
+
+	for _, test := range tests {
+		resp := newTestErrorResponse(test.status, "application/problem+json", `{"title":"Token expired"}`)
+
+		err := resp.Error()
+		qt.Check(t, qt.ErrorIs(err, test.target), qt.Commentf("status %d", test.status))
+		qt.Check(t, qt.ErrorMatches(err, test.msg))
+
+		var p ProblemDetails
+		qt.Check(t, qt.ErrorAs(err, &p), qt.Commentf("status %d", test.status))
+		qt.Check(t, qt.Equals(p.Status, test.status))
+
+		fasthttp.ReleaseResponse(resp.Response)
+	}
+
+	// ErrorResponse body is not included for compatibility.
+	resp := newTestErrorResponse(fasthttp.StatusUnauthorized, "application/json", `{"errors":[{"type":"AuthError","message":"expired"}]}`)
+	defer fasthttp.ReleaseResponse(resp.Response)
+
+	qt.Check(t, qt.Equals(resp.Error(), error(UnauthorizedError{})))
+}
+
+func TestResponseErrorStatusCodes(t *testing.T) {
+	tests := []struct {
+		status int
+		target any
+	}{
+		{fasthttp.StatusBadRequest, new(BadRequestError)},
+		{fasthttp.StatusUnauthorized, new(UnauthorizedError)},
+		{fasthttp.StatusForbidden, new(ForbiddenError)},
+		{fasthttp.StatusNotFound, new(NotFoundError)},
+		{fasthttp.StatusConflict, new(ConflictError)},
+		{fasthttp.StatusUnprocessableEntity, new(UnprocessableEntityError)},
+		{fasthttp.StatusTooManyRequests, new(TooManyRequestsError)},
+		{fasthttp.StatusInternalServerError, new(ServerError)},
+		{fasthttp.StatusServiceUnavailable, new(ServerError)},
+	}
+
+	for _, test := range tests {
+		resp := newTestErrorResponse(test.status, "text/plain", "failed")
+
+		err := resp.Error()
+		qt.Check(t, qt.IsTrue(errors.As(err, test.target)), qt.Commentf("status %d", test.status))
+
+		var sc ResponseStatusCode
+		qt.Check(t, qt.ErrorAs(err, &sc), qt.Commentf("status %d", test.status))
+
+		if sc != nil {
+			qt.Check(t, qt.Equals(sc.StatusCode(), test.status))
+		}
+
+		fasthttp.ReleaseResponse(resp.Response)
+	}
+
+	resp := newTestErrorResponse(fasthttp.StatusTeapot, "text/plain", "failed")
+	defer fasthttp.ReleaseResponse(resp.Response)
+
+	qt.Check(t, qt.ErrorMatches(resp.Error(), "unexpected response status 418: failed"))
+}
+
+func TestResponseErrorTooManyRequests(t *testing.T) {
+	resp := newTestErrorResponse(fasthttp.StatusTooManyRequests, "", "")
+	defer fasthttp.ReleaseResponse(resp.Response)
+
+	resp.Header.Set(fasthttp.HeaderRetryAfter, "120")
+
+	var e TooManyRequestsError
+	qt.Assert(t, qt.ErrorAs(resp.Error(), &e))
+	qt.Check(t, qt.Equals(e.RetryAfter, 2*time.Minute))
+	qt.Check(t, qt.IsNil(e.Err))
+}
+
+var errTestPayment = errors.New("payment required")
+
+func TestClientErrorDecoders(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusPaymentRequired)
+	}
+	s.Start()
+	defer s.Stop()
+
+	decoder := ErrorDecoder(func(resp *Response) error {
+		if resp.StatusCode() == fasthttp.StatusPaymentRequired {
+			return errTestPayment
+		}
+
+		return nil
+	})
+
+	c := NewClient(s.DialContext(), &Configuration{
+		Clients: map[string]NamedClient{
+			"billing": {BaseURL: "http://localhost:8080"},
+			"other":   {BaseURL: "http://localhost:8080"},
+		},
+	}, NamedErrorDecoder{Name: "billing", Decoder: decoder})
+
+	billing, err := c.WithConfiguration("billing")
+	qt.Assert(t, qt.IsNil(err))
+
+	_, err = billing.Get("/")
+	qt.Check(t, qt.ErrorIs(err, errTestPayment))
+
+	other, err := c.WithConfiguration("other")
+	qt.Assert(t, qt.IsNil(err))
+
+	_, err = other.Get("/")
+	qt.Check(t, qt.ErrorMatches(err, "unexpected response status 402"))
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultLogMaxBodySize = 1024
+
+// RequestLogging enables logging of the HTTP client requests using the client logger.
+//
+// Every request attempt is logged with method, URL, status code, duration and sizes.
+// If logger has debug level enabled, request and response headers and optionally
+// truncated bodies are included. Secrets are redacted using Redactor that always
+// redacts common secret headers, query parameters and JSON and form body fields.
+type RequestLogging struct {
+	// Bodies enables logging of request and response bodies at debug level.
+	Bodies bool
+	// MaxBodySize is the maximum number of body bytes logged (defaults to 1024).
+	MaxBodySize int
+	// RedactHeaders is the list of additional header names to redact.
+	RedactHeaders []string
+	// RedactQuery is the list of additional query parameter names to redact.
+	RedactQuery []string
+	// RedactFields is the list of additional JSON and form body field names to redact.
+	RedactFields []string
+}
+
+func (l RequestLogging) apply(o *options) {
+	o.RequestLogging = &l
+}
+
+// requestLogger logs HTTP client requests with redacted sensitive data.
+type requestLogger struct {
+	bodies      bool
+	maxBodySize int
+	redactor    *Redactor
+}
+
+func newRequestLogger(config *RequestLogging) *requestLogger {
+	l := &requestLogger{
+		bodies:      config.Bodies,
+		maxBodySize: config.MaxBodySize,
+		redactor:    NewRedactor(config.RedactHeaders, config.RedactQuery, config.RedactFields),
+	}
+
+	if l.maxBodySize <= 0 {
+		l.maxBodySize = defaultLogMaxBodySize
+	}
+
+	return l
+}
+
+// log writes request attempt log entry.
+func (l *requestLogger) log(logger *zap.Logger, name string, req *Request, resp *Response, attempt int, duration time.Duration, err error) {
+	level := zapcore.InfoLevel
+	if err != nil || resp.StatusCode() >= 500 {
+		level = zapcore.WarnLevel
+	}
+
+	ce := logger.Check(level, "HTTP client request")
+	if ce == nil {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.ByteString("method", req.Header.Method()),
+		zap.String("url", l.redactor.URL(req.URI())),
+		zap.Duration("duration", duration),
+		zap.Int("request_size", len(req.Body())),
+	}
+
+	if name != "" {
+		fields = append(fields, zap.String("client", name))
+	}
+
+	if attempt > 1 {
+		fields = append(fields, zap.Int("attempt", attempt))
+	}
+
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	} else {
+		// Streamed response body must not be read into memory.
+		size := resp.Header.ContentLength()
+		if !resp.IsBodyStream() {
+			size = len(resp.Body())
+		}
+
+		fields = append(fields,
+			zap.Int("status", resp.StatusCode()),
+			zap.Int("response_size", size),
+		)
+	}
+
+	if logger.Core().Enabled(zapcore.DebugLevel) {
+		fields = append(fields, zap.Any("request_headers", l.requestHeaders(req)))
+
+		if l.bodies && !req.IsBodyStream() {
+			fields = append(fields, zap.String("request_body", l.body(req.Header.ContentType(), req.Body())))
+		}
+
+		if err == nil {
+			fields = append(fields, zap.Any("response_headers", l.responseHeaders(resp)))
+
+			if l.bodies && !resp.IsBodyStream() {
+				body, err := resp.BodyUncompressed()
+				if err != nil {
+					body = resp.Body()
+				}
+
+				fields = append(fields, zap.String("response_body", l.body(resp.Header.ContentType(), body)))
+			}
+		}
+	}
+
+	ce.Write(fields...)
+}
+
+func (l *requestLogger) requestHeaders(req *Request) map[string]string {
+	h := make(map[string]string)
+
+	for k, v := range req.Header.All() {
+		h[string(k)] = l.redactor.Header(k, v)
+	}
+
+	return h
+}
+
+func (l *requestLogger) responseHeaders(resp *Response) map[string]string {
+	h := make(map[string]string)
+
+	for k, v := range resp.Header.All() {
+		h[string(k)] = l.redactor.Header(k, v)
+	}
+
+	return h
+}
+
+// body returns redacted and truncated body.
+func (l *requestLogger) body(contentType, body []byte) string {
+	body, err := l.redactor.Body(contentType, body)
+	if err != nil {
+		return "[invalid JSON body omitted]"
+	}
+
+	if len(body) > l.maxBodySize {
+		return string(body[:l.maxBodySize]) + "..."
+	}
+
+	return string(body)
+}
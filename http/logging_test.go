@@ -0,0 +1,137 @@
+package http
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-quicktest/qt"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestLogging(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set(fasthttp.HeaderSetCookie, "session=secret")
+		ctx.SetContentType("application/json; charset=utf-8")
+		ctx.SetBodyString(`{"access_token":"abc","user":{"name":"John","password":"pwd"}}`)
+	}
+	s.Start()
+	defer s.Stop()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	c := NewClient(s.DialContext(), Logger{Logger: zap.New(core)}, RequestLogging{
+		Bodies:       true,
+		RedactQuery:  []string{"session"},
+		RedactFields: []string{"pin"},
+	})
+
+	_, err := c.Post("http://localhost:8080/users?api_key=123&session=abc&X-Amz-Signature=sig&page=1", []byte(`{"pin":"1234","name":"John"}`),
+		WithHeader(fasthttp.HeaderContentType, "application/json"),
+		WithHeader(fasthttp.HeaderAuthorization, "Bearer token"),
+	)
+	qt.Assert(t, qt.IsNil(err))
+
+	entries := logs.FilterMessage("HTTP client request").All()
+	qt.Assert(t, qt.HasLen(entries, 1))
+
+	fields := entries[0].ContextMap()
+	qt.Check(t, qt.Equals(entries[0].Level, zapcore.InfoLevel))
+	qt.Check(t, qt.Equals(fields["method"], any("POST")))
+	qt.Check(t, qt.Equals(fields["url"], any("http://localhost:8080/users?api_key=%5BREDACTED%5D&session=%5BREDACTED%5D&X-Amz-Signature=%5BREDACTED%5D&page=1")))
+	qt.Check(t, qt.Equals(fields["status"], any(int64(fasthttp.StatusOK))))
+	qt.Check(t, qt.Equals(fields["request_size"], any(int64(28))))
+
+	reqHeaders, _ := fields["request_headers"].(map[string]string)
+	qt.Check(t, qt.Equals(reqHeaders[fasthttp.HeaderAuthorization], RedactedValue))
+
+	respHeaders, _ := fields["response_headers"].(map[string]string)
+	qt.Check(t, qt.Equals(respHeaders[fasthttp.HeaderSetCookie], RedactedValue))
+
+	reqBody, _ := fields["request_body"].(string)
+	qt.Check(t, qt.IsTrue(strings.Contains(reqBody, `"pin":"[REDACTED]"`)))
+	qt.Check(t, qt.IsTrue(strings.Contains(reqBody, `"name":"John"`)))
+
+	respBody, _ := fields["response_body"].(string)
+	qt.Check(t, qt.IsFalse(strings.Contains(respBody, "abc")))
+	qt.Check(t, qt.IsFalse(strings.Contains(respBody, "pwd")))
+}
+
+func TestRequestLoggingInfoLevel(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+	}
+	s.Start()
+	defer s.Stop()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	c := NewClient(s.DialContext(), Logger{Logger: zap.New(core)}, RequestLogging{Bodies: true})
+
+	_, err := c.Get("http://localhost:8080")
+	qt.Assert(t, qt.IsNotNil(err))
+
+	entries := logs.FilterMessage("HTTP client request").All()
+	qt.Assert(t, qt.HasLen(entries, 1))
+	qt.Check(t, qt.Equals(entries[0].Level, zapcore.WarnLevel))
+
+	fields := entries[0].ContextMap()
+	qt.Check(t, qt.Equals(fields["status"], any(int64(fasthttp.StatusServiceUnavailable))))
+	qt.Check(t, qt.IsNil(fields["request_headers"]))
+	qt.Check(t, qt.IsNil(fields["response_body"]))
+}
+
+func TestRequestLoggingStreamResponse(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetContentType("text/event-stream")
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			_, _ = w.WriteString("data: hello\n\n")
+			_ = w.Flush()
+
+			// Keep the stream open.
+			<-done
+		})
+	}
+	s.Start()
+	defer s.Stop()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	c := NewClient(s.DialContext(), Logger{Logger: zap.New(core)}, RequestLogging{Bodies: true})
+
+	received := make(chan Event, 1)
+
+	go func() {
+		for ev, err := range StreamEvents(c, "http://localhost/events") {
+			if err == nil {
+				received <- ev
+			}
+
+			break
+		}
+	}()
+
+	select {
+	case ev := <-received:
+		qt.Check(t, qt.Equals(ev.Data, "hello"))
+	case <-time.After(time.Second):
+		t.Fatal("streamed response was not returned")
+	}
+
+	entries := logs.FilterMessage("HTTP client request").All()
+	qt.Assert(t, qt.HasLen(entries, 1))
+
+	fields := entries[0].ContextMap()
+	qt.Check(t, qt.Equals(fields["status"], any(int64(fasthttp.StatusOK))))
+	qt.Check(t, qt.IsNil(fields["response_body"]))
+}
@@ -0,0 +1,520 @@
+package http
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"azugo.io/core/cache"
+
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	defaultOAuth2EarlyExpiry = 30 * time.Second
+	oauth2AssertionLifetime  = 5 * time.Minute
+	oauth2AssertionType      = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	oauth2TokenCacheName     = "http-oauth2-tokens"
+	oauth2TokenTimeout       = 30 * time.Second
+)
+
+// OAuth2ClientCredentials configures the HTTP client to authenticate requests with
+// bearer tokens obtained using the OAuth2 client credentials grant.
+//
+// Tokens are cached and refreshed before they expire. If request is rejected with
+// 401 Unauthorized status code, token is refreshed and request is retried once.
+// Requests that already have the Authorization header set are sent as is.
+type OAuth2ClientCredentials struct {
+	// TokenURL is the identity provider token endpoint URL.
+	TokenURL string
+	// ClientID is the OAuth2 client identifier.
+	ClientID string
+	// ClientSecret is the OAuth2 client secret used with client_secret_basic authentication method.
+	ClientSecret string
+	// Certificate with private key used to sign client assertion with private_key_jwt
+	// authentication method (see cert.ParseTLSCertificateFromFile).
+	// If set, ClientSecret is not used.
+	Certificate *tls.Certificate
+	// KeyID is the key identifier included in the client assertion header.
+	KeyID string
+	// Scopes to request.
+	Scopes []string
+	// Audience to request.
+	Audience string
+	// EarlyExpiry is the duration before token expiry when token is refreshed (defaults to 30s).
+	EarlyExpiry time.Duration
+	// Cache to store tokens in. If not set, the client Cache is used if configured,
+	// otherwise tokens are cached in memory.
+	Cache *cache.Cache
+	// TLSConfig is the TLS configuration used for token requests. If not set,
+	// the client TLS configuration is used.
+	TLSConfig *tls.Config
+	// TokenClient is used to send token requests. By default token requests are
+	// sent with a separate client that uses the dialer, proxy and TLS settings
+	// of the API client and times out after 30 seconds.
+	TokenClient *fasthttp.Client
+}
+
+func (c OAuth2ClientCredentials) apply(o *options) {
+	o.OAuth2 = &c
+	o.tokens = nil
+}
+
+// OAuth2Error is returned when identity provider rejects token request.
+type OAuth2Error struct {
+	// StatusCode is the token endpoint response status code.
+	StatusCode int `json:"-"`
+	// Code is the OAuth2 error code.
+	Code string `json:"error"`
+	// Description is the human readable error description.
+	Description string `json:"error_description"`
+}
+
+func (e OAuth2Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("oauth2: token request failed with status %d", e.StatusCode)
+	}
+
+	if e.Description == "" {
+		return "oauth2: " + e.Code
+	}
+
+	return "oauth2: " + e.Code + ": " + e.Description
+}
+
+type oauth2Token struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	Expiry      time.Time `json:"expiry"`
+}
+
+func (t *oauth2Token) valid(now time.Time, early time.Duration) bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || now.Add(early).Before(t.Expiry))
+}
+
+func (t *oauth2Token) header() string {
+	typ := t.TokenType
+	if typ == "" || strings.EqualFold(typ, "bearer") {
+		typ = "Bearer"
+	}
+
+	return typ + " " + t.AccessToken
+}
+
+// tokenSource fetches and caches OAuth2 tokens shared between clients derived from the same client.
+type tokenSource struct {
+	config *OAuth2ClientCredentials
+	client *fasthttp.Client
+	cache  *cache.Cache
+	key    string
+
+	lock    sync.Mutex
+	token   *oauth2Token
+	pending *tokenFetch
+}
+
+func newTokenSource(config *OAuth2ClientCredentials, opts *options) *tokenSource {
+	c := *config
+
+	if c.EarlyExpiry <= 0 {
+		c.EarlyExpiry = defaultOAuth2EarlyExpiry
+	}
+
+	client := c.TokenClient
+	if client == nil {
+		tlsConfig := c.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = opts.TLSConfig
+		}
+
+		client = &fasthttp.Client{
+			Name:         opts.UserAgent,
+			TLSConfig:    tlsConfig,
+			Dial:         dialFunc(opts),
+			ReadTimeout:  oauth2TokenTimeout,
+			WriteTimeout: oauth2TokenTimeout,
+		}
+	}
+
+	store := c.Cache
+	if store == nil {
+		store = opts.Cache
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{c.TokenURL, c.ClientID, strings.Join(c.Scopes, " "), c.Audience}, "\n")))
+
+	return &tokenSource{
+		config: &c,
+		client: client,
+		cache:  store,
+		key:    hex.EncodeToString(sum[:]),
+	}
+}
+
+// cached returns the cache instance used to store tokens or nil if tokens are cached only in memory.
+func (s *tokenSource) cached() (cache.Instance[oauth2Token], error) {
+	if s.cache == nil {
+		return nil, nil
+	}
+
+	return cache.GetOrCreate[oauth2Token](s.cache, oauth2TokenCacheName)
+}
+
+// acquire returns valid token fetching a new one if needed.
+//
+// If invalid is not empty and matches the current token, a new token is fetched.
+// Concurrent requests share the same token fetch and stop waiting for it
+// when their context is canceled.
+func (s *tokenSource) acquire(ctx context.Context, invalid string) (*oauth2Token, error) {
+	s.lock.Lock()
+
+	if s.token != nil && invalid != "" && s.token.header() == invalid {
+		s.token = nil
+	}
+
+	if s.token.valid(time.Now(), s.config.EarlyExpiry) {
+		token := s.token
+		s.lock.Unlock()
+
+		return token, nil
+	}
+
+	f := s.pending
+	if f == nil {
+		f = &tokenFetch{done: make(chan struct{})}
+		s.pending = f
+
+		// Token is fetched independently of the request context so that canceled
+		// request does not fail other requests waiting for the same token.
+		go s.refresh(context.WithoutCancel(ctx), f, invalid)
+	}
+
+	s.lock.Unlock()
+
+	select {
+	case <-f.done:
+		return f.token, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// tokenFetch is the token fetch in progress shared by concurrent requests.
+type tokenFetch struct {
+	done  chan struct{}
+	token *oauth2Token
+	err   error
+}
+
+// refresh loads the token from the cache or fetches a new one and completes the fetch.
+func (s *tokenSource) refresh(ctx context.Context, f *tokenFetch, invalid string) {
+	ctx, cancel := context.WithTimeout(ctx, oauth2TokenTimeout)
+	defer cancel()
+
+	f.token, f.err = s.load(ctx, invalid)
+
+	s.lock.Lock()
+	if f.err == nil {
+		s.token = f.token
+	}
+
+	s.pending = nil
+	s.lock.Unlock()
+
+	close(f.done)
+}
+
+// load returns valid token from the cache if it is not invalid, otherwise fetches and caches a new one.
+func (s *tokenSource) load(ctx context.Context, invalid string) (*oauth2Token, error) {
+	now := time.Now()
+
+	instance, err := s.cached()
+	if err != nil {
+		return nil, err
+	}
+
+	if instance != nil {
+		token, err := instance.Get(ctx, s.key)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.valid(now, s.config.EarlyExpiry) && token.header() != invalid {
+			return &token, nil
+		}
+	}
+
+	token, err := s.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if instance != nil {
+		var opts []cache.ItemOption[oauth2Token]
+		if !token.Expiry.IsZero() {
+			opts = append(opts, cache.TTL[oauth2Token](token.Expiry.Sub(now)))
+		}
+
+		if err := instance.Set(ctx, s.key, *token, opts...); err != nil {
+			return nil, err
+		}
+	}
+
+	return token, nil
+}
+
+func (s *tokenSource) fetch(ctx context.Context) (*oauth2Token, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(s.config.TokenURL)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType("application/x-www-form-urlencoded")
+	req.Header.Set(fasthttp.HeaderAccept, "application/json")
+
+	args := req.PostArgs()
+	args.Set("grant_type", "client_credentials")
+
+	if len(s.config.Scopes) > 0 {
+		args.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+
+	if s.config.Audience != "" {
+		args.Set("audience", s.config.Audience)
+	}
+
+	if s.config.Certificate != nil {
+		assertion, err := s.assertion()
+		if err != nil {
+			return nil, err
+		}
+
+		args.Set("client_id", s.config.ClientID)
+		args.Set("client_assertion_type", oauth2AssertionType)
+		args.Set("client_assertion", assertion)
+	} else {
+		req.Header.Set(fasthttp.HeaderAuthorization, "Basic "+base64.StdEncoding.EncodeToString([]byte(url.QueryEscape(s.config.ClientID)+":"+url.QueryEscape(s.config.ClientSecret))))
+	}
+
+	if err := doContext(ctx, s.client, req, resp); err != nil {
+		return nil, fmt.Errorf("oauth2: token request failed: %w", err)
+	}
+
+	body := resp.Body()
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		e := OAuth2Error{}
+		_ = json.Unmarshal(body, &e)
+		e.StatusCode = resp.StatusCode()
+
+		return nil, e
+	}
+
+	tr := struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, fmt.Errorf("oauth2: invalid token response: %w", err)
+	}
+
+	if tr.AccessToken == "" {
+		return nil, errors.New("oauth2: token response does not contain access token")
+	}
+
+	token := &oauth2Token{
+		AccessToken: tr.AccessToken,
+		TokenType:   tr.TokenType,
+	}
+
+	if tr.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
+
+// doContext sends the request and waits for response or until context is canceled.
+//
+// Request is sent in the background if the context can be canceled, so that
+// waiting for the response can be aborted without closing the connection.
+func doContext(ctx context.Context, c *fasthttp.Client, req *fasthttp.Request, resp *fasthttp.Response) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+
+	send := func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		if ok {
+			return c.DoDeadline(req, resp, deadline)
+		}
+
+		return c.Do(req, resp)
+	}
+
+	if ctx.Done() == nil {
+		return send(req, resp)
+	}
+
+	r := fasthttp.AcquireRequest()
+	rs := fasthttp.AcquireResponse()
+
+	req.CopyTo(r)
+
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- send(r, rs)
+	}()
+
+	release := func() {
+		fasthttp.ReleaseRequest(r)
+		fasthttp.ReleaseResponse(rs)
+	}
+
+	select {
+	case err := <-errc:
+		rs.CopyTo(resp)
+		release()
+
+		return err
+	case <-ctx.Done():
+		go func() {
+			<-errc
+			release()
+		}()
+
+		return ctx.Err()
+	}
+}
+
+// assertion returns signed JWT client assertion for private_key_jwt authentication method.
+func (s *tokenSource) assertion() (string, error) {
+	crt := s.config.Certificate
+
+	alg, sign, err := jwtSigner(crt.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	header := map[string]string{
+		"alg": alg,
+		"typ": "JWT",
+	}
+
+	if s.config.KeyID != "" {
+		header["kid"] = s.config.KeyID
+	}
+
+	if len(crt.Certificate) > 0 {
+		sum := sha256.Sum256(crt.Certificate[0])
+		header["x5t#S256"] = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	claims := map[string]any{
+		"iss": s.config.ClientID,
+		"sub": s.config.ClientID,
+		"aud": s.config.TokenURL,
+		"jti": hex.EncodeToString(jti),
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(oauth2AssertionLifetime).Unix(),
+	}
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+
+	p, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(p)
+
+	sig, err := sign([]byte(unsigned))
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// jwtSigner returns JWT algorithm name and signing function for the private key.
+func jwtSigner(key crypto.PrivateKey) (string, func(data []byte) ([]byte, error), error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return "RS256", func(data []byte) ([]byte, error) {
+			sum := sha256.Sum256(data)
+
+			return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, sum[:])
+		}, nil
+	case *ecdsa.PrivateKey:
+		var (
+			alg  string
+			hash crypto.Hash
+		)
+
+		switch k.Curve.Params().BitSize {
+		case 256:
+			alg, hash = "ES256", crypto.SHA256
+		case 384:
+			alg, hash = "ES384", crypto.SHA384
+		case 521:
+			alg, hash = "ES512", crypto.SHA512
+		default:
+			return "", nil, fmt.Errorf("oauth2: unsupported elliptic curve %s", k.Curve.Params().Name)
+		}
+
+		return alg, func(data []byte) ([]byte, error) {
+			h := hash.New()
+			h.Write(data)
+
+			r, s, err := ecdsa.Sign(rand.Reader, k, h.Sum(nil))
+			if err != nil {
+				return nil, err
+			}
+
+			size := (k.Curve.Params().BitSize + 7) / 8
+			sig := make([]byte, 2*size)
+			r.FillBytes(sig[:size])
+			s.FillBytes(sig[size:])
+
+			return sig, nil
+		}, nil
+	case ed25519.PrivateKey:
+		return "EdDSA", func(data []byte) ([]byte, error) {
+			return ed25519.Sign(k, data), nil
+		}, nil
+	default:
+		return "", nil, fmt.Errorf("oauth2: unsupported private key type %T", key)
+	}
+}
@@ -0,0 +1,300 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"azugo.io/core/cache"
+	"azugo.io/core/cert"
+
+	"github.com/go-quicktest/qt"
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+)
+
+func newTestOAuth2Server(t *testing.T, tokens *atomic.Int32, check func(ctx *fasthttp.RequestCtx)) *testHttpServer {
+	t.Helper()
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		switch string(ctx.Path()) {
+		case "/token":
+			qt.Check(t, qt.Equals(string(ctx.PostArgs().Peek("grant_type")), "client_credentials"))
+			qt.Check(t, qt.Equals(string(ctx.PostArgs().Peek("scope")), "read write"))
+
+			if check != nil {
+				check(ctx)
+			}
+
+			n := tokens.Add(1)
+
+			ctx.SetContentType("application/json")
+			ctx.SetBodyString(`{"access_token":"token` + string(rune('0'+n)) + `","token_type":"bearer","expires_in":3600}`)
+		case "/api":
+			// First issued token is considered revoked.
+			if string(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization)) == "Bearer token1" && string(ctx.QueryArgs().Peek("revoked")) == "1" {
+				ctx.SetStatusCode(fasthttp.StatusUnauthorized)
+				return
+			}
+
+			ctx.SetBody(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization))
+		default:
+			ctx.SetStatusCode(fasthttp.StatusNotFound)
+		}
+	}
+	s.Start()
+
+	return s
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	var tokens atomic.Int32
+
+	s := newTestOAuth2Server(t, &tokens, func(ctx *fasthttp.RequestCtx) {
+		qt.Check(t, qt.Equals(string(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization)), "Basic "+base64.StdEncoding.EncodeToString([]byte("client:secret"))))
+	})
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), OAuth2ClientCredentials{
+		TokenURL:     "http://localhost:8080/token",
+		TokenClient:  s.Client(),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+
+	for range 2 {
+		body, err := c.Get("http://localhost:8080/api")
+		qt.Assert(t, qt.IsNil(err))
+		qt.Check(t, qt.Equals(string(body), "Bearer token1"))
+	}
+
+	qt.Check(t, qt.Equals(tokens.Load(), int32(1)))
+
+	// Token is refreshed and request retried once when rejected.
+	body, err := c.Get("http://localhost:8080/api?revoked=1")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), "Bearer token2"))
+	qt.Check(t, qt.Equals(tokens.Load(), int32(2)))
+
+	// Explicit authorization header is not overridden.
+	body, err = c.Get("http://localhost:8080/api", WithHeader(fasthttp.HeaderAuthorization, "Bearer custom"))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), "Bearer custom"))
+}
+
+func TestOAuth2ClientCredentialsCache(t *testing.T) {
+	var tokens atomic.Int32
+
+	s := newTestOAuth2Server(t, &tokens, nil)
+	defer s.Stop()
+
+	ca := cache.New(cache.MemoryCache)
+	qt.Assert(t, qt.IsNil(ca.Start(context.Background())))
+	defer ca.Close()
+
+	opt := OAuth2ClientCredentials{
+		TokenURL:     "http://localhost:8080/token",
+		TokenClient:  s.Client(),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+		Cache:        ca,
+	}
+
+	for range 2 {
+		body, err := NewClient(s.DialContext(), opt).Get("http://localhost:8080/api")
+		qt.Assert(t, qt.IsNil(err))
+		qt.Check(t, qt.Equals(string(body), "Bearer token1"))
+
+		// Wait for memory cache to store the value.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Client cache is used if token source does not have its own cache.
+	opt.Cache = nil
+
+	body, err := NewClient(s.DialContext(), opt, Cache{ca}).Get("http://localhost:8080/api")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), "Bearer token1"))
+	qt.Check(t, qt.Equals(tokens.Load(), int32(1)))
+}
+
+func TestOAuth2StreamBodyNotRetried(t *testing.T) {
+	var tokens atomic.Int32
+
+	s := newTestOAuth2Server(t, &tokens, nil)
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), OAuth2ClientCredentials{
+		TokenURL:     "http://localhost:8080/token",
+		TokenClient:  s.Client(),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+
+	// Consumed request body stream can not be sent again.
+	_, err := c.Upload("http://localhost:8080/api?revoked=1", strings.NewReader("data"), -1)
+	qt.Check(t, qt.ErrorAs(err, new(UnauthorizedError)))
+
+	// Rejected token is still refreshed for the next request.
+	qt.Check(t, qt.Equals(tokens.Load(), int32(2)))
+
+	body, err := c.Get("http://localhost:8080/api?revoked=1")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), "Bearer token2"))
+}
+
+func TestOAuth2PrivateKeyJWT(t *testing.T) {
+	der, priv, err := cert.CreateDevPEM("localhost")
+	qt.Assert(t, qt.IsNil(err))
+
+	key, _ := priv.(*ecdsa.PrivateKey)
+
+	var tokens atomic.Int32
+
+	s := newTestOAuth2Server(t, &tokens, func(ctx *fasthttp.RequestCtx) {
+		args := ctx.PostArgs()
+		qt.Check(t, qt.IsNil(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization)))
+		qt.Check(t, qt.Equals(string(args.Peek("client_id")), "client"))
+		qt.Check(t, qt.Equals(string(args.Peek("client_assertion_type")), oauth2AssertionType))
+
+		parts := strings.Split(string(args.Peek("client_assertion")), ".")
+		qt.Assert(t, qt.HasLen(parts, 3))
+
+		header := map[string]string{}
+		h, _ := base64.RawURLEncoding.DecodeString(parts[0])
+		qt.Assert(t, qt.IsNil(json.Unmarshal(h, &header)))
+		qt.Check(t, qt.Equals(header["alg"], "ES256"))
+		qt.Check(t, qt.Equals(header["kid"], "key1"))
+
+		claims := map[string]any{}
+		p, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		qt.Assert(t, qt.IsNil(json.Unmarshal(p, &claims)))
+		qt.Check(t, qt.Equals(claims["iss"], any("client")))
+		qt.Check(t, qt.Equals(claims["aud"], any("http://localhost:8080/token")))
+
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		qt.Assert(t, qt.HasLen(sig, 64))
+
+		sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		r, ss := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		qt.Check(t, qt.IsTrue(ecdsa.Verify(&key.PublicKey, sum[:], r, ss)))
+	})
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), OAuth2ClientCredentials{
+		TokenURL:    "http://localhost:8080/token",
+		TokenClient: s.Client(),
+		ClientID:    "client",
+		Certificate: &tls.Certificate{
+			Certificate: [][]byte{der},
+			PrivateKey:  priv,
+		},
+		KeyID:  "key1",
+		Scopes: []string{"read", "write"},
+	})
+
+	body, err := c.Get("http://localhost:8080/api")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), "Bearer token1"))
+}
+
+func TestOAuth2TokenError(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.SetBodyString(`{"error":"invalid_client","error_description":"Client authentication failed"}`)
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), OAuth2ClientCredentials{
+		TokenURL:    "http://localhost:8080/token",
+		TokenClient: s.Client(),
+		ClientID:    "client",
+	})
+
+	_, err := c.Get("http://localhost:8080/api")
+
+	var oerr OAuth2Error
+	qt.Assert(t, qt.ErrorAs(err, &oerr))
+	qt.Check(t, qt.Equals(oerr.StatusCode, fasthttp.StatusBadRequest))
+	qt.Check(t, qt.Equals(oerr.Code, "invalid_client"))
+}
+
+func TestOAuth2DefaultTokenClient(t *testing.T) {
+	var tokens atomic.Int32
+
+	s := newTestOAuth2Server(t, &tokens, nil)
+	defer s.Stop()
+
+	// Token requests are sent using the client dialer.
+	c := NewClient(s.DialContext(), OAuth2ClientCredentials{
+		TokenURL:     "http://localhost:8080/token",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+
+	body, err := c.Get("http://localhost:8080/api")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), "Bearer token1"))
+}
+
+func TestOAuth2TokenRequestCanceled(t *testing.T) {
+	var tokens atomic.Int32
+
+	hang := make(chan struct{})
+
+	s := newTestOAuth2Server(t, &tokens, func(*fasthttp.RequestCtx) {
+		<-hang
+	})
+	defer s.Stop()
+
+	c := NewClient(s.DialContext(), OAuth2ClientCredentials{
+		TokenURL:     "http://localhost:8080/token",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+
+	var wg sync.WaitGroup
+
+	// Requests waiting for the hung token request are aborted by their own context.
+	for range 3 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			start := time.Now()
+
+			_, err := c.WithContext(ctx).Get("http://localhost:8080/api")
+			qt.Check(t, qt.ErrorIs(err, context.Canceled))
+			qt.Check(t, qt.IsTrue(time.Since(start) < time.Second))
+		}()
+	}
+
+	wg.Wait()
+
+	close(hang)
+
+	body, err := c.Get("http://localhost:8080/api")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), "Bearer token1"))
+	qt.Check(t, qt.Equals(tokens.Load(), int32(1)))
+}
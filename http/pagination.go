@@ -0,0 +1,194 @@
+package http
+
+import (
+	"iter"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"azugo.io/core/paginator"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Pagination configures how Paginate requests the following pages.
+//
+// By default the next page URL is taken from the RFC 8288 Link response header
+// with rel="next" relation. If PageQuery is enabled, page and per_page query
+// parameters are used instead and pages are requested until the page has less
+// items than PerPage or no items at all.
+//
+// Pagination is a request option that has effect only when passed to Paginate.
+type Pagination struct {
+	// MaxPages is the maximum number of pages to request (defaults to 0 meaning no limit).
+	MaxPages int
+	// PageQuery enables page and per_page query parameter stepping instead of following Link headers.
+	PageQuery bool
+	// PerPage is the page size to request with PageQuery.
+	PerPage int
+	// StartPage is the first page number to request with PageQuery (defaults to 1).
+	StartPage int
+}
+
+func (Pagination) apply(*Request) {}
+
+// Paginate returns an iterator over items of all pages starting from the specified URL.
+// Each page response is decoded as an array of T.
+//
+// Iteration stops after the first error that is yielded with zero value of T.
+// Use WithContext request option to cancel iteration.
+func Paginate[T any](c Client, url string, opt ...RequestOption) iter.Seq2[T, error] {
+	var p Pagination
+
+	for _, o := range opt {
+		if v, ok := o.(Pagination); ok {
+			p = v
+		}
+	}
+
+	if p.StartPage <= 0 {
+		p.StartPage = 1
+	}
+
+	return func(yield func(T, error) bool) {
+		next, page := url, p.StartPage
+
+		for n := 0; p.MaxPages <= 0 || n < p.MaxPages; n++ {
+			pageOpt := opt
+
+			switch {
+			case p.PageQuery:
+				pageOpt = append(pageOpt[:len(pageOpt):len(pageOpt)], WithQueryArg(paginator.QueryParameterPage, strconv.Itoa(page), true))
+				if p.PerPage > 0 {
+					pageOpt = append(pageOpt, WithQueryArg(paginator.QueryParameterPerPage, strconv.Itoa(p.PerPage), true))
+				}
+			case n > 0:
+				// Next page link already contains all query parameters.
+				pageOpt = withoutQueryArgs(opt)
+			}
+
+			var (
+				items []T
+				link  string
+			)
+
+			err := doDecode(c, fasthttp.MethodGet, next, nil, pageOpt, &items, func(req *Request, resp *Response) {
+				if !p.PageQuery {
+					link = nextLink(req.URI().String(), resp)
+				}
+			})
+			if err != nil {
+				var zero T
+
+				yield(zero, err)
+
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			switch {
+			case p.PageQuery && len(items) > 0 && len(items) >= p.PerPage:
+				page++
+			case !p.PageQuery && link != "":
+				next = link
+			default:
+				return
+			}
+		}
+	}
+}
+
+func withoutQueryArgs(opt []RequestOption) []RequestOption {
+	res := make([]RequestOption, 0, len(opt))
+
+	for _, o := range opt {
+		if _, ok := o.(*requestQueryArg); !ok {
+			res = append(res, o)
+		}
+	}
+
+	return res
+}
+
+// nextLink returns absolute URL of the rel="next" link from the response Link header.
+func nextLink(current string, resp *Response) string {
+	for _, v := range resp.Header.PeekAll(fasthttp.HeaderLink) {
+		if link, ok := ParseLinks(string(v))["next"]; ok {
+			base, err := url.Parse(current)
+			if err != nil {
+				return link
+			}
+
+			ref, err := url.Parse(link)
+			if err != nil {
+				return ""
+			}
+
+			return base.ResolveReference(ref).String()
+		}
+	}
+
+	return ""
+}
+
+// ParseLinks parses RFC 8288 Link header value and returns link URLs by their relation type.
+func ParseLinks(v string) map[string]string {
+	links := make(map[string]string)
+
+	for v != "" {
+		start := strings.IndexByte(v, '<')
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(v[start:], '>')
+		if end < 0 {
+			break
+		}
+
+		target := v[start+1 : start+end]
+		v = v[start+end+1:]
+
+		// Parameters end at the next link (comma outside of quoted string).
+		params, rest := splitLinkParams(v)
+		v = rest
+
+		for param := range strings.SplitSeq(params, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+				continue
+			}
+
+			for rel := range strings.FieldsSeq(strings.Trim(strings.TrimSpace(value), `"`)) {
+				rel = strings.ToLower(rel)
+				if _, exists := links[rel]; !exists {
+					links[rel] = target
+				}
+			}
+		}
+	}
+
+	return links
+}
+
+func splitLinkParams(v string) (string, string) {
+	quoted := false
+
+	for i := range len(v) {
+		switch v[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				return v[:i], v[i+1:]
+			}
+		}
+	}
+
+	return v, ""
+}
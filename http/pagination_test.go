@@ -0,0 +1,184 @@
+package http
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"azugo.io/core/paginator"
+
+	"github.com/go-quicktest/qt"
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+)
+
+func newPaginationTestServer(t *testing.T, total int, requests *atomic.Int32) *testHttpServer {
+	t.Helper()
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		requests.Add(1)
+
+		page, _ := strconv.Atoi(string(ctx.QueryArgs().Peek(paginator.QueryParameterPage)))
+		perPage, _ := strconv.Atoi(string(ctx.QueryArgs().Peek(paginator.QueryParameterPerPage)))
+
+		if perPage <= 0 {
+			perPage = 2
+		}
+
+		p := paginator.New(total, perPage, page)
+
+		u, _ := url.Parse(ctx.URI().String())
+		p.SetURL(u)
+
+		offset := (p.Current() - 1) * perPage
+
+		items := make([]int, 0, perPage)
+		for i := offset; i < min(offset+perPage, total); i++ {
+			items = append(items, i+1)
+		}
+
+		if links := p.Links(); len(links) > 0 {
+			ctx.Response.Header.Set(fasthttp.HeaderLink, strings.Join(links, ", "))
+		}
+
+		buf, _ := json.Marshal(items)
+		ctx.SetContentType("application/json")
+		ctx.SetBody(buf)
+	}
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	return s
+}
+
+func collect[T any](t *testing.T, seq func(func(T, error) bool)) ([]T, error) {
+	t.Helper()
+
+	var items []T
+
+	for item, err := range seq {
+		if err != nil {
+			return items, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func TestPaginateLinks(t *testing.T) {
+	var requests atomic.Int32
+
+	s := newPaginationTestServer(t, 5, &requests)
+	c := NewClient(s.DialContext())
+
+	items, err := collect(t, Paginate[int](c, "http://localhost/items", WithQueryArg("filter", "all")))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.DeepEquals(items, []int{1, 2, 3, 4, 5}))
+	qt.Check(t, qt.Equals(requests.Load(), int32(3)))
+}
+
+func TestPaginateMaxPages(t *testing.T) {
+	var requests atomic.Int32
+
+	s := newPaginationTestServer(t, 10, &requests)
+	c := NewClient(s.DialContext())
+
+	items, err := collect(t, Paginate[int](c, "http://localhost/items", Pagination{MaxPages: 2}))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.DeepEquals(items, []int{1, 2, 3, 4}))
+	qt.Check(t, qt.Equals(requests.Load(), int32(2)))
+}
+
+func TestPaginateBreak(t *testing.T) {
+	var requests atomic.Int32
+
+	s := newPaginationTestServer(t, 10, &requests)
+	c := NewClient(s.DialContext())
+
+	for item, err := range Paginate[int](c, "http://localhost/items") {
+		qt.Assert(t, qt.IsNil(err))
+
+		if item == 3 {
+			break
+		}
+	}
+
+	qt.Check(t, qt.Equals(requests.Load(), int32(2)))
+}
+
+func TestPaginatePageQuery(t *testing.T) {
+	var requests atomic.Int32
+
+	s := newPaginationTestServer(t, 7, &requests)
+	c := NewClient(s.DialContext())
+
+	items, err := collect(t, Paginate[int](c, "http://localhost/items", Pagination{PageQuery: true, PerPage: 3}))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.DeepEquals(items, []int{1, 2, 3, 4, 5, 6, 7}))
+	qt.Check(t, qt.Equals(requests.Load(), int32(3)))
+}
+
+func TestPaginateError(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		if len(ctx.QueryArgs().Peek("page")) > 0 {
+			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+
+			return
+		}
+
+		ctx.Response.Header.Set(fasthttp.HeaderLink, `</items?page=2>; rel="next"`)
+		ctx.SetContentType("application/json")
+		ctx.SetBodyString(`[1]`)
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	items, err := collect(t, Paginate[int](c, "http://localhost/items"))
+	qt.Check(t, qt.DeepEquals(items, []int{1}))
+	qt.Check(t, qt.ErrorAs(err, new(ServerError)))
+}
+
+func TestPaginateContextCanceled(t *testing.T) {
+	var requests atomic.Int32
+
+	s := newPaginationTestServer(t, 10, &requests)
+	c := NewClient(s.DialContext())
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	items, err := collect(t, func(yield func(int, error) bool) {
+		for item, err := range Paginate[int](c, "http://localhost/items", WithContext(ctx)) {
+			if item == 2 {
+				cancel()
+			}
+
+			if !yield(item, err) {
+				return
+			}
+		}
+	})
+	qt.Check(t, qt.DeepEquals(items, []int{1, 2}))
+	qt.Check(t, qt.ErrorIs(err, context.Canceled))
+	qt.Check(t, qt.Equals(requests.Load(), int32(1)))
+}
+
+func TestParseLinks(t *testing.T) {
+	links := ParseLinks(`<https://example.com/items?page=2>; rel="next", <https://example.com/items?page=5>; title="a, b"; rel="last"` +
+		`, </items?page=1>; rel="first prev"`)
+
+	qt.Check(t, qt.DeepEquals(links, map[string]string{
+		"next":  "https://example.com/items?page=2",
+		"last":  "https://example.com/items?page=5",
+		"first": "/items?page=1",
+		"prev":  "/items?page=1",
+	}))
+}
@@ -0,0 +1,212 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit limits the rate of outgoing requests of the HTTP client.
+//
+// Requests exceeding the limit wait until allowed or until the request context is canceled.
+type RateLimit struct {
+	// Rate is the number of requests allowed per second.
+	Rate float64
+	// Burst is the maximum number of requests allowed at once (defaults to rate rounded up).
+	Burst int
+	// FailFast makes requests exceeding the limit fail immediately with RateLimitExceededError.
+	FailFast bool
+}
+
+func (r RateLimit) apply(o *options) {
+	o.RateLimit = &r
+	o.limiter = nil
+}
+
+// RateLimitExceededError is returned when request exceeds the client rate limit in fail fast mode.
+type RateLimitExceededError struct {
+	// RetryAfter is the time left until the request would be allowed.
+	RetryAfter time.Duration
+}
+
+func (e RateLimitExceededError) Error() string {
+	return fmt.Sprintf("client rate limit exceeded, retry after %s", e.RetryAfter)
+}
+
+// rateLimiter is a token bucket rate limiter.
+type rateLimiter struct {
+	lock     sync.Mutex
+	failFast bool
+	rate     float64
+	burst    float64
+	tokens   float64
+	last     time.Time
+}
+
+func newRateLimiter(config *RateLimit) *rateLimiter {
+	if config.Rate <= 0 {
+		return nil
+	}
+
+	burst := float64(config.Burst)
+	if burst <= 0 {
+		burst = math.Ceil(config.Rate)
+	}
+
+	return &rateLimiter{
+		failFast: config.FailFast,
+		rate:     config.Rate,
+		burst:    burst,
+		tokens:   burst,
+		last:     time.Now(),
+	}
+}
+
+// reserve takes a token and returns the delay after which the request is allowed.
+func (l *rateLimiter) reserve() time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns the reserved token.
+func (l *rateLimiter) cancel() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+// wait blocks until request is allowed or context is canceled.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay > 0 && l.failFast {
+		l.cancel()
+
+		return RateLimitExceededError{RetryAfter: delay}
+	}
+
+	if err := sleep(ctx, delay); err != nil {
+		l.cancel()
+
+		return err
+	}
+
+	return nil
+}
+
+// Bulkhead limits the number of concurrent requests of the HTTP client.
+//
+// Requests exceeding the limit wait for a free slot or until the request context is canceled.
+// Requests with streamed response body hold the slot until the response body stream
+// is closed or the response is released.
+type Bulkhead struct {
+	// MaxConcurrent is the maximum number of requests in flight.
+	MaxConcurrent int
+	// FailFast makes requests exceeding the limit fail immediately with BulkheadFullError.
+	FailFast bool
+}
+
+func (b Bulkhead) apply(o *options) {
+	o.Bulkhead = &b
+	o.bulkhead = nil
+}
+
+// BulkheadFullError is returned when the maximum number of concurrent requests is reached in fail fast mode.
+type BulkheadFullError struct {
+	// MaxConcurrent is the maximum number of requests in flight.
+	MaxConcurrent int
+}
+
+func (e BulkheadFullError) Error() string {
+	return fmt.Sprintf("client has reached maximum of %d concurrent requests", e.MaxConcurrent)
+}
+
+// bulkhead is a semaphore limiting the number of concurrent requests.
+type bulkhead struct {
+	slots    chan struct{}
+	failFast bool
+}
+
+func newBulkhead(config *Bulkhead) *bulkhead {
+	if config.MaxConcurrent <= 0 {
+		return nil
+	}
+
+	return &bulkhead{
+		slots:    make(chan struct{}, config.MaxConcurrent),
+		failFast: config.FailFast,
+	}
+}
+
+// acquire blocks until the slot is available or context is canceled.
+func (b *bulkhead) acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if b.failFast {
+		return BulkheadFullError{MaxConcurrent: cap(b.slots)}
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees the acquired slot.
+func (b *bulkhead) release() {
+	<-b.slots
+}
+
+// queue waits for the rate limiter and acquires the bulkhead slot. Returned
+// function must be called to release the slot after the request is done.
+func (c client) queue(ctx context.Context, req *Request, attempt int) (func(), error) {
+	if c.limiter == nil && c.bulkhead == nil {
+		return func() {}, nil
+	}
+
+	finish := c.Instrumenter.Observe(ctx, InstrumentationRequestQueue, req, attempt)
+
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx); err != nil {
+			finish(err)
+
+			return nil, err
+		}
+	}
+
+	if c.bulkhead != nil {
+		if err := c.bulkhead.acquire(ctx); err != nil {
+			finish(err)
+
+			return nil, err
+		}
+
+		finish(nil)
+
+		return c.bulkhead.release, nil
+	}
+
+	finish(nil)
+
+	return func() {}, nil
+}
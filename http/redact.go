@@ -0,0 +1,181 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+)
+
+// RedactedValue replaces values of redacted headers, query parameters and body fields.
+const RedactedValue = "[REDACTED]"
+
+var (
+	defaultRedactHeaders = []string{
+		fasthttp.HeaderAuthorization,
+		fasthttp.HeaderProxyAuthorization,
+		fasthttp.HeaderCookie,
+		fasthttp.HeaderSetCookie,
+	}
+	defaultRedactFields = []string{
+		"password",
+		"secret",
+		"token",
+		"access_token",
+		"refresh_token",
+		"id_token",
+		"client_secret",
+		"client_assertion",
+	}
+	defaultRedactQuery = []string{
+		"token",
+		"access_token",
+		"refresh_token",
+		"id_token",
+		"client_secret",
+		"api_key",
+		"apikey",
+		"signature",
+		"sig",
+		"X-Amz-Credential",
+		"X-Amz-Security-Token",
+		"X-Amz-Signature",
+	}
+)
+
+// Redactor replaces secrets in HTTP headers, query parameters and JSON and
+// form bodies with RedactedValue.
+//
+// Authorization, Proxy-Authorization, Cookie and Set-Cookie headers, common
+// secret query parameters (token, access_token, refresh_token, id_token,
+// client_secret, api_key, apikey, signature, sig and AWS presigned URL
+// credentials and signature) and common secret body fields (password, secret,
+// token, access_token, refresh_token, id_token, client_secret and
+// client_assertion) are always redacted. Names are matched case-insensitively.
+type Redactor struct {
+	headers map[string]struct{}
+	query   map[string]struct{}
+	fields  map[string]struct{}
+}
+
+// NewRedactor creates a new redactor that also redacts the additional headers,
+// query parameters and body fields.
+func NewRedactor(headers, query, fields []string) *Redactor {
+	return &Redactor{
+		headers: redactSet(defaultRedactHeaders, headers),
+		query:   redactSet(defaultRedactQuery, query),
+		fields:  redactSet(defaultRedactFields, fields),
+	}
+}
+
+func redactSet(lists ...[]string) map[string]struct{} {
+	m := make(map[string]struct{})
+
+	for _, list := range lists {
+		for _, k := range list {
+			m[strings.ToLower(k)] = struct{}{}
+		}
+	}
+
+	return m
+}
+
+func redacted(set map[string]struct{}, key []byte) bool {
+	_, ok := set[strings.ToLower(string(key))]
+
+	return ok
+}
+
+// Header returns the header value or RedactedValue if the header must be redacted.
+func (r *Redactor) Header(key, value []byte) string {
+	if redacted(r.headers, key) {
+		return RedactedValue
+	}
+
+	return string(value)
+}
+
+// URL returns the URL with redacted query parameters.
+func (r *Redactor) URL(uri *fasthttp.URI) string {
+	if len(uri.QueryString()) == 0 {
+		return uri.String()
+	}
+
+	u := fasthttp.AcquireURI()
+	defer fasthttp.ReleaseURI(u)
+
+	uri.CopyTo(u)
+
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+
+	redactArgs(args, uri.QueryArgs(), r.query)
+	u.SetQueryStringBytes(args.QueryString())
+
+	return u.String()
+}
+
+// Body returns the body with redacted JSON or form fields. Bodies of other
+// content types are returned as is. Error is returned if JSON body is invalid.
+func (r *Redactor) Body(contentType, body []byte) ([]byte, error) {
+	if len(body) == 0 {
+		return body, nil
+	}
+
+	mt := mediaType(contentType)
+
+	switch {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(r.redactJSON(v))
+	case mt == "application/x-www-form-urlencoded":
+		src := fasthttp.AcquireArgs()
+		defer fasthttp.ReleaseArgs(src)
+
+		args := fasthttp.AcquireArgs()
+		defer fasthttp.ReleaseArgs(args)
+
+		src.ParseBytes(body)
+		redactArgs(args, src, r.fields)
+
+		return args.AppendBytes(nil), nil
+	default:
+		return body, nil
+	}
+}
+
+// redactArgs copies arguments from src to dst redacting values of the keys in the set.
+func redactArgs(dst, src *fasthttp.Args, set map[string]struct{}) {
+	for k, v := range src.All() {
+		if redacted(set, k) {
+			v = []byte(RedactedValue)
+		}
+
+		dst.AddBytesKV(k, v)
+	}
+}
+
+func (r *Redactor) redactJSON(v any) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		for k, val := range vv {
+			if _, ok := r.fields[strings.ToLower(k)]; ok {
+				vv[k] = RedactedValue
+
+				continue
+			}
+
+			vv[k] = r.redactJSON(val)
+		}
+	case []any:
+		for i, val := range vv {
+			vv[i] = r.redactJSON(val)
+		}
+	}
+
+	return v
+}
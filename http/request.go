@@ -1,8 +1,10 @@
 package http
 
 import (
+	"context"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/valyala/fasthttp"
 )
@@ -10,7 +12,10 @@ import (
 // Request represents an HTTP request.
 type Request struct {
 	*fasthttp.Request
-	client Client
+	client  Client
+	ctx     context.Context
+	timeout time.Duration
+	codec   Codec
 }
 
 // NewRequest creates a new HTTP request.
@@ -32,6 +37,9 @@ func (c client) NewRequest() *Request {
 	req, _ := v.(*Request)
 	req.Request = freq
 	req.client = c
+	req.ctx = nil
+	req.timeout = 0
+	req.codec = nil
 
 	return req
 }
@@ -41,6 +49,9 @@ func (c client) ReleaseRequest(req *Request) {
 	r := req.Request
 	req.Request = nil
 	req.client = nil
+	req.ctx = nil
+	req.timeout = 0
+	req.codec = nil
 
 	fasthttp.ReleaseRequest(r)
 	c.RequestPool.Put(req)
@@ -60,6 +71,11 @@ func (r *Request) SetRequestURL(u string) error {
 	return nil
 }
 
+// Context returns the request context or nil if the request does not have its own context.
+func (r *Request) Context() context.Context {
+	return r.ctx
+}
+
 // BaseURL returns the base URL of the client.
 func (r *Request) BaseURL() string {
 	return r.client.BaseURL()
@@ -145,3 +161,51 @@ func WithBody(body []byte) RequestOption {
 		Body: body,
 	}
 }
+
+type requestContext struct {
+	Context context.Context
+}
+
+func (c *requestContext) apply(r *Request) {
+	r.ctx = c.Context
+}
+
+// WithContext sets the context for the request.
+//
+// Request is aborted when either the request or the client context is canceled.
+func WithContext(ctx context.Context) RequestOption {
+	return &requestContext{
+		Context: ctx,
+	}
+}
+
+type requestTimeout struct {
+	Timeout time.Duration
+}
+
+func (t *requestTimeout) apply(r *Request) {
+	r.timeout = t.Timeout
+}
+
+// WithTimeout sets the timeout for the request including all retry attempts.
+func WithTimeout(timeout time.Duration) RequestOption {
+	return &requestTimeout{
+		Timeout: timeout,
+	}
+}
+
+type requestCodec struct {
+	Codec Codec
+}
+
+func (c *requestCodec) apply(r *Request) {
+	r.codec = c.Codec
+}
+
+// WithCodec sets the codec used to encode request body and to decode response
+// body in typed request helpers (defaults to CodecJSON).
+func WithCodec(codec Codec) RequestOption {
+	return &requestCodec{
+		Codec: codec,
+	}
+}
@@ -2,7 +2,11 @@ package http
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/valyala/fasthttp"
@@ -16,6 +20,24 @@ import (
 // Response instance MUST NOT be used from concurrently running goroutines.
 type Response struct {
 	*fasthttp.Response
+	decoders []ErrorDecoder
+	release  func()
+}
+
+// CloseBodyStream closes the response body stream if it is set.
+func (r *Response) CloseBodyStream() error {
+	err := r.Response.CloseBodyStream()
+	r.releaseSlot()
+
+	return err
+}
+
+// releaseSlot releases the bulkhead slot and endpoint held by the streamed response.
+func (r *Response) releaseSlot() {
+	if r.release != nil {
+		r.release()
+		r.release = nil
+	}
 }
 
 // Success returns true if the response status code is 2xx.
@@ -24,38 +46,116 @@ func (r Response) Success() bool {
 }
 
 // Error if the response status code is not 2xx.
+//
+// Custom error decoders registered for the client are tried first. Otherwise
+// well known status codes are mapped to typed errors with error details
+// from RFC 9457 problem details body if available. ErrorResponse body is
+// returned as is for status codes other than 401, 403 and 404 as before.
 func (r Response) Error() error {
 	if r.Success() {
 		return nil
 	}
 
+	for _, d := range r.decoders {
+		if err := d(&r); err != nil {
+			return err
+		}
+	}
+
+	details := r.errorDetails()
+
+	var problem error
+	if p, ok := details.(ProblemDetails); ok {
+		problem = p
+	}
+
 	switch r.StatusCode() {
 	case fasthttp.StatusForbidden:
-		return ForbiddenError{}
+		return ForbiddenError{Err: problem}
 	case fasthttp.StatusNotFound:
-		return NotFoundError{}
+		return NotFoundError{Err: problem}
 	case fasthttp.StatusUnauthorized:
-		return UnauthorizedError{}
+		return UnauthorizedError{Err: problem}
+	}
+
+	if e, ok := details.(ErrorResponse); ok {
+		return e
+	}
+
+	body := details
+	if body == nil {
+		body = r.bodyError()
+	}
+
+	switch status := r.StatusCode(); {
+	case status == fasthttp.StatusBadRequest:
+		return BadRequestError{Err: body}
+	case status == fasthttp.StatusConflict:
+		return ConflictError{Err: body}
+	case status == fasthttp.StatusUnprocessableEntity:
+		return UnprocessableEntityError{Err: body}
+	case status == fasthttp.StatusTooManyRequests:
+		after, _ := parseRetryAfter(r.Header.Peek(fasthttp.HeaderRetryAfter), time.Now())
+
+		return TooManyRequestsError{RetryAfter: after, Err: body}
+	case status >= 500 && status < 600:
+		return ServerError{Status: status, Err: body}
+	case details != nil:
+		return details
+	case body != nil:
+		return fmt.Errorf("unexpected response status %d: %w", status, body)
 	default:
-		body, _ := r.BodyUncompressed()
-		if len(body) > 0 && bytes.Equal(r.Header.ContentType(), strContentTypeJSON) {
-			e := ErrorResponse{}
-			if err := json.Unmarshal(body, &e); err == nil && len(e.Errors) > 0 {
-				return e
-			}
-		}
+		return fmt.Errorf("unexpected response status %d", status)
+	}
+}
+
+// errorDetails returns error details parsed from RFC 9457 problem details or
+// ErrorResponse body or nil if response does not contain them.
+func (r Response) errorDetails() error {
+	body, _ := r.BodyUncompressed()
+	if len(body) == 0 {
+		return nil
+	}
 
-		bodyLen := len(body)
-		if bodyLen > 0 {
-			if bodyLen > 100 {
-				bodyLen = 100
+	switch mediaType(r.Header.ContentType()) {
+	case "application/problem+json":
+		p := ProblemDetails{}
+		if err := json.Unmarshal(body, &p); err == nil {
+			if p.Status == 0 {
+				p.Status = r.StatusCode()
 			}
 
-			body = append([]byte(": "), body[:bodyLen]...)
+			return p
 		}
+	case "application/json":
+		e := ErrorResponse{}
+		if err := json.Unmarshal(body, &e); err == nil && len(e.Errors) > 0 {
+			return e
+		}
+	}
 
-		return fmt.Errorf("unexpected response status %d%s", r.StatusCode(), body)
+	return nil
+}
+
+// bodyError returns error with the beginning of the response body or nil if body is empty.
+func (r Response) bodyError() error {
+	body, _ := r.BodyUncompressed()
+	if len(body) == 0 {
+		return nil
 	}
+
+	if len(body) > 100 {
+		body = body[:100]
+	}
+
+	return errors.New(string(body))
+}
+
+// mediaType returns lower case media type without parameters.
+func mediaType(contentType []byte) string {
+	mt, _, _ := bytes.Cut(contentType, []byte{';'})
+
+	return strings.ToLower(string(bytes.TrimSpace(mt)))
 }
 
 // NewResponse returns a new response instance.
@@ -66,11 +166,13 @@ func (c client) NewResponse() *Response {
 	if v == nil {
 		return &Response{
 			Response: fasthttp.AcquireResponse(),
+			decoders: c.errorDecoders(),
 		}
 	}
 
 	res, _ := v.(*Response)
 	res.Response = fasthttp.AcquireResponse()
+	res.decoders = c.errorDecoders()
 
 	return res
 }
@@ -79,7 +181,19 @@ func (c client) NewResponse() *Response {
 func (c client) ReleaseResponse(res *Response) {
 	r := res.Response
 	res.Response = nil
+	res.decoders = nil
 
 	fasthttp.ReleaseResponse(r)
+	res.releaseSlot()
 	c.ResponsePool.Put(res)
 }
+
+// errorDecoders returns error decoders for the client with named client decoders first.
+func (c client) errorDecoders() []ErrorDecoder {
+	named := c.NamedErrorDecoders[c.name]
+	if len(named) == 0 {
+		return c.ErrorDecoders
+	}
+
+	return slices.Concat(named, c.ErrorDecoders)
+}
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBackoff     = 100 * time.Millisecond
+	defaultRetryMaxBackoff  = 5 * time.Second
+	defaultRetryJitter      = 0.2
+)
+
+// Retry configures automatic retries of the failed requests.
+//
+// By default only idempotent requests (GET, HEAD, OPTIONS, TRACE, PUT and DELETE)
+// are retried on connection errors and on 429, 502, 503 and 504 response status codes.
+type Retry struct {
+	// MaxAttempts is the maximum number of attempts including the first one (defaults to 3).
+	MaxAttempts int
+	// Backoff is the delay before the first retry (defaults to 100ms).
+	// Delay is doubled for each following retry.
+	Backoff time.Duration
+	// MaxBackoff is the maximum delay between attempts (defaults to 5s).
+	// If server responds with Retry-After header that exceeds this value request is not retried.
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the delay that is randomized (defaults to 0.2).
+	// Use negative value to disable jitter.
+	Jitter float64
+	// RetryStatus reports if request should be retried for the response status code.
+	RetryStatus func(statusCode int) bool
+	// RetryError reports if request should be retried for the error.
+	// By default all errors except context cancellation are retried.
+	RetryError func(err error) bool
+	// RetryNonIdempotent enables retries for non-idempotent requests (e.g. POST and PATCH).
+	RetryNonIdempotent bool
+	// IgnoreRetryAfter disables Retry-After response header support.
+	IgnoreRetryAfter bool
+}
+
+func (r Retry) apply(o *options) {
+	o.Retry = &r
+}
+
+// DefaultRetryStatus returns true for 429, 502, 503 and 504 response status codes.
+func DefaultRetryStatus(statusCode int) bool {
+	switch statusCode {
+	case fasthttp.StatusTooManyRequests,
+		fasthttp.StatusBadGateway,
+		fasthttp.StatusServiceUnavailable,
+		fasthttp.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func defaultRetryError(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func (r *Retry) maxAttempts() int {
+	if r.MaxAttempts <= 0 {
+		return defaultRetryMaxAttempts
+	}
+
+	return r.MaxAttempts
+}
+
+func isIdempotent(req *Request) bool {
+	h := &req.Header
+
+	return h.IsGet() || h.IsHead() || h.IsOptions() || h.IsTrace() || h.IsPut() || h.IsDelete()
+}
+
+// shouldRetry reports if request should be retried and returns the delay before the next attempt.
+func (r *Retry) shouldRetry(req *Request, resp *Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= r.maxAttempts() || req.IsBodyStream() {
+		return 0, false
+	}
+
+	if !r.RetryNonIdempotent && !isIdempotent(req) {
+		return 0, false
+	}
+
+	if err != nil {
+		retryErr := r.RetryError
+		if retryErr == nil {
+			retryErr = defaultRetryError
+		}
+
+		if !retryErr(err) {
+			return 0, false
+		}
+
+		return r.backoff(attempt), true
+	}
+
+	retryStatus := r.RetryStatus
+	if retryStatus == nil {
+		retryStatus = DefaultRetryStatus
+	}
+
+	if !retryStatus(resp.StatusCode()) {
+		return 0, false
+	}
+
+	delay := r.backoff(attempt)
+
+	if !r.IgnoreRetryAfter {
+		if after, ok := parseRetryAfter(resp.Header.Peek(fasthttp.HeaderRetryAfter), time.Now()); ok {
+			if after > r.maxBackoff() {
+				return 0, false
+			}
+
+			delay = max(delay, after)
+		}
+	}
+
+	return delay, true
+}
+
+func (r *Retry) maxBackoff() time.Duration {
+	if r.MaxBackoff <= 0 {
+		return defaultRetryMaxBackoff
+	}
+
+	return r.MaxBackoff
+}
+
+// backoff returns exponential delay with jitter after the specified attempt.
+func (r *Retry) backoff(attempt int) time.Duration {
+	delay := r.Backoff
+	if delay <= 0 {
+		delay = defaultRetryBackoff
+	}
+
+	maxDelay := r.maxBackoff()
+
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	delay = min(delay, maxDelay)
+
+	jitter := r.Jitter
+	if jitter == 0 {
+		jitter = defaultRetryJitter
+	}
+
+	if jitter > 0 {
+		jitter = min(jitter, 1)
+		delta := time.Duration(float64(delay) * jitter)
+		delay = delay - delta + rand.N(2*delta+1) //nolint:gosec
+	}
+
+	return delay
+}
+
+// parseRetryAfter parses Retry-After header value that can be either
+// delay in seconds or HTTP date.
+func parseRetryAfter(v []byte, now time.Time) (time.Duration, bool) {
+	if len(v) == 0 {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(string(v)); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+
+		return time.Duration(secs) * time.Second, true
+	}
+
+	t, err := fasthttp.ParseHTTPDate(v)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(t.Sub(now), 0), true
+}
+
+// sleep waits for the specified duration or until context is canceled.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// InstrRequestAttempt returns request attempt number if the operation is HTTP client request event.
+//
+// First attempt has number 1.
+func InstrRequestAttempt(op string, args ...any) (int, bool) {
+	if op != InstrumentationRequest || len(args) < 3 {
+		return 0, false
+	}
+
+	attempt, ok := args[2].(int)
+
+	return attempt, ok
+}
@@ -41,6 +41,15 @@ func (s *testHttpServer) Stop() {
 	}
 }
 
+// Client returns the client that sends all requests to the test server.
+func (s *testHttpServer) Client() *fasthttp.Client {
+	return &fasthttp.Client{
+		Dial: func(string) (net.Conn, error) {
+			return s.ln.Dial()
+		},
+	}
+}
+
 func (s *testHttpServer) DialContext() DialContextFunc {
 	return func(ctx context.Context, network, addr string) (net.Conn, error) {
 		return s.ln.Dial()
@@ -0,0 +1,490 @@
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Request components that can be included in the HMAC signature besides request headers.
+const (
+	// SignMethod is the request method.
+	SignMethod = "@method"
+	// SignPath is the escaped request path.
+	SignPath = "@path"
+	// SignQuery is the escaped request query string.
+	SignQuery = "@query"
+	// SignTimestamp is the Unix time of signing.
+	SignTimestamp = "@timestamp"
+	// SignBody is the request body.
+	SignBody = "@body"
+	// SignBodyDigest is the hex encoded SHA-256 digest of the request body.
+	SignBodyDigest = "@body-sha256"
+)
+
+var errSignBodyStream = errors.New("can not sign streamed request body")
+
+// sign signs the request attempt with the client signers. Requests are signed
+// after the load balancer endpoint is selected and response cache conditional
+// headers are set.
+func (c client) sign(ctx context.Context, req *Request) error {
+	for _, sign := range c.Signers {
+		if err := sign(ctx, req); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HMACSigner signs requests with HMAC signature of the canonical request that
+// consists of configured request components joined by the separator.
+//
+// Signer can be used as client option or its Sign method as RequestFunc. Client
+// option signs every request attempt after the load balancer endpoint is selected.
+type HMACSigner struct {
+	// KeyID identifies the secret key in the signature header.
+	KeyID string
+	// Secret is the shared secret key.
+	Secret []byte
+	// Algorithm is the hash algorithm (sha1, sha256 or sha512, defaults to sha256).
+	Algorithm string
+	// Encoding is the signature encoding (hex or base64, defaults to hex).
+	Encoding string
+	// Components are the request components included in the canonical request
+	// in the specified order. Component is either a header name or one of
+	// Sign* constants (defaults to method, path, query, timestamp and body digest).
+	Components []string
+	// Separator is put between canonical request components (defaults to newline).
+	Separator string
+	// Header is the request header to set the signature to (defaults to X-Signature).
+	Header string
+	// TimestampHeader is the request header to set the signing Unix time to
+	// if the timestamp is included in the signature (defaults to X-Timestamp).
+	TimestampHeader string
+	// Format is the signature header value format with {signature}, {key_id},
+	// {algorithm}, {timestamp} and {headers} placeholders (defaults to {signature}).
+	// Headers placeholder is replaced with space separated names of signed headers.
+	Format string
+
+	now func() time.Time
+}
+
+func (s HMACSigner) apply(o *options) {
+	o.Signers = append(o.Signers, s.Sign)
+}
+
+func (s HMACSigner) algorithm() string {
+	if s.Algorithm == "" {
+		return "sha256"
+	}
+
+	return strings.ToLower(s.Algorithm)
+}
+
+func (s HMACSigner) hash() (func() hash.Hash, error) {
+	switch s.algorithm() {
+	case "sha1":
+		return sha1.New, nil
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported HMAC algorithm %q", s.Algorithm)
+	}
+}
+
+func (s HMACSigner) components() []string {
+	if len(s.Components) == 0 {
+		return []string{SignMethod, SignPath, SignQuery, SignTimestamp, SignBodyDigest}
+	}
+
+	return s.Components
+}
+
+// Sign signs the request and sets the signature header.
+func (s HMACSigner) Sign(_ context.Context, req *Request) error {
+	h, err := s.hash()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+
+	canonical, headers, err := s.canonical(req, timestamp)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(h, s.Secret)
+	_, _ = mac.Write(canonical)
+
+	var signature string
+
+	switch strings.ToLower(s.Encoding) {
+	case "", "hex":
+		signature = hex.EncodeToString(mac.Sum(nil))
+	case "base64":
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	default:
+		return fmt.Errorf("unsupported signature encoding %q", s.Encoding)
+	}
+
+	format := s.Format
+	if format == "" {
+		format = "{signature}"
+	}
+
+	header := s.Header
+	if header == "" {
+		header = "X-Signature"
+	}
+
+	req.Header.Set(header, strings.NewReplacer(
+		"{signature}", signature,
+		"{key_id}", s.KeyID,
+		"{algorithm}", s.algorithm(),
+		"{timestamp}", timestamp,
+		"{headers}", strings.Join(headers, " "),
+	).Replace(format))
+
+	return nil
+}
+
+// canonical returns the canonical request and names of signed headers.
+func (s HMACSigner) canonical(req *Request, timestamp string) ([]byte, []string, error) {
+	sep := s.Separator
+	if sep == "" {
+		sep = "\n"
+	}
+
+	var (
+		buf     []byte
+		headers []string
+	)
+
+	path, query, _ := strings.Cut(string(req.URI().RequestURI()), "?")
+
+	for i, c := range s.components() {
+		if i > 0 {
+			buf = append(buf, sep...)
+		}
+
+		switch strings.ToLower(c) {
+		case SignMethod:
+			buf = append(buf, req.Header.Method()...)
+		case SignPath:
+			buf = append(buf, path...)
+		case SignQuery:
+			buf = append(buf, query...)
+		case SignTimestamp:
+			th := s.TimestampHeader
+			if th == "" {
+				th = "X-Timestamp"
+			}
+
+			req.Header.Set(th, timestamp)
+
+			buf = append(buf, timestamp...)
+		case SignBody, SignBodyDigest:
+			if req.IsBodyStream() {
+				return nil, nil, errSignBodyStream
+			}
+
+			if strings.EqualFold(c, SignBody) {
+				buf = append(buf, req.Body()...)
+
+				break
+			}
+
+			sum := sha256.Sum256(req.Body())
+			buf = hex.AppendEncode(buf, sum[:])
+		default:
+			name := strings.ToLower(c)
+			headers = append(headers, name)
+
+			if name == "host" {
+				buf = append(buf, requestHost(req)...)
+			} else {
+				buf = append(buf, strings.TrimSpace(string(req.Header.Peek(c)))...)
+			}
+		}
+	}
+
+	return buf, headers, nil
+}
+
+// requestHost returns the host the request is sent to.
+func requestHost(req *Request) string {
+	if host := req.Header.Host(); req.UseHostHeader && len(host) > 0 {
+		return string(host)
+	}
+
+	return string(req.URI().Host())
+}
+
+const (
+	awsAlgorithm       = "AWS4-HMAC-SHA256"
+	awsTimeFormat      = "20060102T150405Z"
+	awsDateFormat      = "20060102"
+	awsUnsignedPayload = "UNSIGNED-PAYLOAD"
+
+	headerAmzDate          = "X-Amz-Date"
+	headerAmzSecurityToken = "X-Amz-Security-Token"
+	headerAmzContentSHA256 = "X-Amz-Content-Sha256"
+)
+
+// awsIgnoredHeaders are headers that are not signed as they can be changed in transit.
+var awsIgnoredHeaders = []string{
+	"authorization",
+	"connection",
+	"content-length",
+	"expect",
+	"transfer-encoding",
+	"user-agent",
+	"x-amzn-trace-id",
+}
+
+// AWSSigV4 signs requests with AWS Signature Version 4.
+//
+// Signer can be used as client option or its Sign method as RequestFunc. Client
+// option signs every request attempt after the load balancer endpoint is selected.
+// For Amazon S3 service the request path is not escaped again and the payload
+// hash is sent in X-Amz-Content-Sha256 header.
+type AWSSigV4 struct {
+	// AccessKeyID is the AWS access key ID.
+	AccessKeyID string
+	// SecretAccessKey is the AWS secret access key.
+	SecretAccessKey string
+	// SessionToken is the optional temporary security credentials session token.
+	SessionToken string
+	// Region is the AWS region (e.g. us-east-1).
+	Region string
+	// Service is the AWS service name (e.g. s3).
+	Service string
+	// UnsignedPayload disables signing of the request body. Streamed request
+	// bodies are never signed.
+	UnsignedPayload bool
+
+	now func() time.Time
+}
+
+func (s AWSSigV4) apply(o *options) {
+	o.Signers = append(o.Signers, s.Sign)
+}
+
+func (s AWSSigV4) time() time.Time {
+	if s.now != nil {
+		return s.now().UTC()
+	}
+
+	return time.Now().UTC()
+}
+
+func (s AWSSigV4) scope(t time.Time) string {
+	return t.Format(awsDateFormat) + "/" + s.Region + "/" + s.Service + "/aws4_request"
+}
+
+// Sign signs the request and sets the Authorization header.
+func (s AWSSigV4) Sign(_ context.Context, req *Request) error {
+	t := s.time()
+
+	req.Header.Set(headerAmzDate, t.Format(awsTimeFormat))
+
+	if s.SessionToken != "" {
+		req.Header.Set(headerAmzSecurityToken, s.SessionToken)
+	}
+
+	payload := awsUnsignedPayload
+	if !s.UnsignedPayload && !req.IsBodyStream() {
+		sum := sha256.Sum256(req.Body())
+		payload = hex.EncodeToString(sum[:])
+	}
+
+	if s.Service == "s3" {
+		req.Header.Set(headerAmzContentSHA256, payload)
+	}
+
+	headers := [][2]string{{"host", requestHost(req)}}
+
+	for k, v := range req.Header.All() {
+		name := strings.ToLower(string(k))
+		if name == "host" || slices.Contains(awsIgnoredHeaders, name) {
+			continue
+		}
+
+		headers = append(headers, [2]string{name, string(v)})
+	}
+
+	query := make([][2]string, 0, req.URI().QueryArgs().Len())
+	for k, v := range req.URI().QueryArgs().All() {
+		query = append(query, [2]string{string(k), string(v)})
+	}
+
+	signed, signature := s.signature(t, string(req.Header.Method()), req.URI(), query, headers, payload)
+
+	req.Header.Set(fasthttp.HeaderAuthorization, awsAlgorithm+
+		" Credential="+s.AccessKeyID+"/"+s.scope(t)+
+		", SignedHeaders="+signed+
+		", Signature="+signature)
+
+	return nil
+}
+
+// Presign returns the URL with query parameters that authorize the request with
+// the specified method for the duration of expires without any other credentials.
+//
+// Only the host header is signed and request body is not signed.
+func (s AWSSigV4) Presign(method, url string, expires time.Duration) (string, error) {
+	u := fasthttp.AcquireURI()
+	defer fasthttp.ReleaseURI(u)
+
+	if err := u.Parse(nil, []byte(url)); err != nil {
+		return "", err
+	}
+
+	t := s.time()
+
+	args := u.QueryArgs()
+	args.Set("X-Amz-Algorithm", awsAlgorithm)
+	args.Set("X-Amz-Credential", s.AccessKeyID+"/"+s.scope(t))
+	args.Set(headerAmzDate, t.Format(awsTimeFormat))
+	args.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	args.Set("X-Amz-SignedHeaders", "host")
+
+	if s.SessionToken != "" {
+		args.Set(headerAmzSecurityToken, s.SessionToken)
+	}
+
+	query := make([][2]string, 0, args.Len())
+	for k, v := range args.All() {
+		query = append(query, [2]string{string(k), string(v)})
+	}
+
+	_, signature := s.signature(t, method, u, query, [][2]string{{"host", string(u.Host())}}, awsUnsignedPayload)
+
+	args.Set("X-Amz-Signature", signature)
+
+	return u.String(), nil
+}
+
+// signature returns signed header names and the signature of the canonical request.
+func (s AWSSigV4) signature(t time.Time, method string, u *fasthttp.URI, query, headers [][2]string, payload string) (string, string) {
+	path, _, _ := strings.Cut(string(u.RequestURI()), "?")
+	if path == "" {
+		path = "/"
+	}
+
+	if s.Service != "s3" {
+		path = awsEscape(path, false)
+	}
+
+	for i := range query {
+		query[i] = [2]string{awsEscape(query[i][0], true), awsEscape(query[i][1], true)}
+	}
+
+	slices.SortFunc(query, func(a, b [2]string) int {
+		if c := strings.Compare(a[0], b[0]); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a[1], b[1])
+	})
+
+	// Values of the headers with the same name are joined by comma.
+	values := make(map[string][]string, len(headers))
+	for _, h := range headers {
+		values[h[0]] = append(values[h[0]], strings.Join(strings.Fields(h[1]), " "))
+	}
+
+	names := slices.Sorted(func(yield func(string) bool) {
+		for name := range values {
+			if !yield(name) {
+				return
+			}
+		}
+	})
+
+	var canonical strings.Builder
+
+	canonical.WriteString(method + "\n" + path + "\n")
+
+	for i, q := range query {
+		if i > 0 {
+			canonical.WriteByte('&')
+		}
+
+		canonical.WriteString(q[0] + "=" + q[1])
+	}
+
+	canonical.WriteByte('\n')
+
+	for _, name := range names {
+		canonical.WriteString(name + ":" + strings.Join(values[name], ",") + "\n")
+	}
+
+	signed := strings.Join(names, ";")
+
+	canonical.WriteString("\n" + signed + "\n" + payload)
+
+	hashed := sha256.Sum256([]byte(canonical.String()))
+
+	stringToSign := awsAlgorithm + "\n" + t.Format(awsTimeFormat) + "\n" + s.scope(t) + "\n" + hex.EncodeToString(hashed[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), t.Format(awsDateFormat))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+
+	return signed, hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+// awsEscape escapes all characters except unreserved ones as required by AWS
+// Signature Version 4. Slash is escaped only if escapeSlash is true.
+func awsEscape(s string, escapeSlash bool) string {
+	const upperhex = "0123456789ABCDEF"
+
+	var buf strings.Builder
+
+	for i := range len(s) {
+		c := s[i]
+
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !escapeSlash:
+			buf.WriteByte(c)
+		default:
+			buf.WriteByte('%')
+			buf.WriteByte(upperhex[c>>4])
+			buf.WriteByte(upperhex[c&15])
+		}
+	}
+
+	return buf.String()
+}
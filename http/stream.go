@@ -0,0 +1,445 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	headerLastEventID = "Last-Event-ID"
+
+	contentTypeEventStream = "text/event-stream"
+	contentTypeNDJSON      = "application/x-ndjson"
+
+	defaultEventRetryDelay = 3 * time.Second
+	maxStreamLineSize      = 1 << 20
+	streamPrefetchSize     = 8 << 10
+)
+
+var errStreamClosed = errors.New("response stream closed")
+
+// Event is a Server-Sent Event received from the text/event-stream response.
+type Event struct {
+	// ID is the last event ID set by the server.
+	ID string
+	// Type is the event type (defaults to "message").
+	Type string
+	// Data is the event data with multiple data lines joined by a newline.
+	Data string
+}
+
+// Decode unmarshals the event data as JSON into v.
+func (e Event) Decode(v any) error {
+	return CodecJSON.Unmarshal([]byte(e.Data), v)
+}
+
+// EventStream configures how StreamEvents reconnects to the event stream.
+//
+// EventStream is a request option that has effect only when passed to StreamEvents.
+type EventStream struct {
+	// LastEventID is the event ID to resume the stream from.
+	LastEventID string
+	// RetryDelay is the delay before reconnecting unless set by the server with retry field (defaults to 3 seconds).
+	RetryDelay time.Duration
+	// MaxReconnects is the maximum number of consecutive reconnects without receiving any event (defaults to 0 meaning no limit).
+	MaxReconnects int
+	// NoReconnect disables reconnecting when the stream ends or the connection is lost.
+	NoReconnect bool
+}
+
+func (EventStream) apply(*Request) {}
+
+// UnexpectedContentTypeError is returned when the stream response has unexpected content type.
+type UnexpectedContentTypeError struct {
+	// ContentType is the content type of the response.
+	ContentType string
+}
+
+func (e UnexpectedContentTypeError) Error() string {
+	return fmt.Sprintf("unexpected response content type %q", e.ContentType)
+}
+
+// StreamEvents returns an iterator over Server-Sent Events received from the specified URL.
+//
+// When the stream ends or the connection is lost the request is sent again with
+// Last-Event-ID header after the retry delay. Responses with error status or
+// other content type than text/event-stream end the iteration with an error,
+// 204 No Content response ends it without an error.
+//
+// Iteration stops after the first error that is yielded with zero value of Event.
+// Use WithContext request option to cancel iteration. Client and request
+// timeouts apply to each connection including reading the stream.
+func StreamEvents(c Client, url string, opt ...RequestOption) iter.Seq2[Event, error] {
+	var es EventStream
+
+	for _, o := range opt {
+		if v, ok := o.(EventStream); ok {
+			es = v
+		}
+	}
+
+	if es.RetryDelay <= 0 {
+		es.RetryDelay = defaultEventRetryDelay
+	}
+
+	return func(yield func(Event, error) bool) {
+		ctx, cancel := streamContext(c, opt)
+		defer cancel()
+
+		r := eventReader{id: es.LastEventID, retry: es.RetryDelay}
+
+		for reconnects := 0; ; reconnects++ {
+			s, retry, err := openStream(ctx, c, url, opt, contentTypeEventStream, func(req *Request) {
+				req.Header.Set(fasthttp.HeaderCacheControl, "no-cache")
+
+				if r.id != "" {
+					req.Header.Set(headerLastEventID, r.id)
+				}
+			})
+			if err != nil && !retry {
+				yield(Event{}, err)
+
+				return
+			}
+
+			if s == nil && err == nil {
+				return
+			}
+
+			if s != nil {
+				var received, done bool
+
+				received, done, err = r.read(s, yield)
+				s.Close()
+
+				if done {
+					return
+				}
+
+				if received {
+					reconnects = 0
+				}
+			}
+
+			if ctx.Err() != nil {
+				yield(Event{}, ctx.Err())
+
+				return
+			}
+
+			if es.NoReconnect || (es.MaxReconnects > 0 && reconnects >= es.MaxReconnects) {
+				if err != nil {
+					yield(Event{}, err)
+				}
+
+				return
+			}
+
+			if err := sleep(ctx, r.retry); err != nil {
+				yield(Event{}, err)
+
+				return
+			}
+		}
+	}
+}
+
+// eventReader parses text/event-stream keeping the last event ID and retry delay between connections.
+type eventReader struct {
+	id    string
+	retry time.Duration
+}
+
+// read yields events from the stream and reports if any events were received
+// and if the iteration was stopped by the consumer.
+func (r *eventReader) read(s *responseStream, yield func(Event, error) bool) (bool, bool, error) {
+	var (
+		typ      string
+		data     strings.Builder
+		hasData  bool
+		received bool
+	)
+
+	sc := s.scanner(scanEventLines)
+
+	for sc.Scan() {
+		line := sc.Bytes()
+
+		if len(line) == 0 {
+			if hasData {
+				ev := Event{ID: r.id, Type: typ, Data: data.String()}
+				if ev.Type == "" {
+					ev.Type = "message"
+				}
+
+				received = true
+
+				if !yield(ev, nil) {
+					return received, true, nil
+				}
+			}
+
+			typ, hasData = "", false
+			data.Reset()
+
+			continue
+		}
+
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := bytes.Cut(line, []byte{':'})
+		value = bytes.TrimPrefix(value, []byte{' '})
+
+		switch string(field) {
+		case "event":
+			typ = string(value)
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+
+			data.Write(value)
+
+			hasData = true
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				r.id = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 32); err == nil {
+				r.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	return received, false, sc.Err()
+}
+
+// scanEventLines is a split function for bufio.Scanner that splits lines ending
+// with CRLF, LF or CR. Incomplete last line is discarded.
+func scanEventLines(data []byte, atEOF bool) (int, []byte, error) {
+	i := bytes.IndexAny(data, "\r\n")
+	if i < 0 {
+		return 0, nil, nil
+	}
+
+	if data[i] == '\r' {
+		if i+1 == len(data) && !atEOF {
+			// Need more data to check if CR is followed by LF.
+			return 0, nil, nil
+		}
+
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+	}
+
+	return i + 1, data[:i], nil
+}
+
+// StreamNDJSON returns an iterator over values decoded from the newline-delimited
+// JSON response received from the specified URL. Empty lines are skipped.
+//
+// Iteration stops after the first error that is yielded with zero value of T.
+// Use WithContext request option to cancel iteration. Client and request
+// timeouts apply to reading the stream.
+func StreamNDJSON[T any](c Client, url string, opt ...RequestOption) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		ctx, cancel := streamContext(c, opt)
+		defer cancel()
+
+		s, _, err := openStream(ctx, c, url, opt, contentTypeNDJSON, nil)
+		if err != nil {
+			yield(zero, err)
+
+			return
+		}
+
+		if s == nil {
+			return
+		}
+
+		defer s.Close()
+
+		sc := s.scanner(bufio.ScanLines)
+
+		for sc.Scan() {
+			line := bytes.TrimSpace(sc.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+
+			var v T
+
+			if err := CodecJSON.Unmarshal(line, &v); err != nil {
+				yield(zero, err)
+
+				return
+			}
+
+			if !yield(v, nil) {
+				return
+			}
+		}
+
+		if err := sc.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// responseStream reads the response body in the background so that reading
+// can be aborted as soon as the context is canceled.
+type responseStream struct {
+	r      *io.PipeReader
+	stop   func() bool
+	cancel context.CancelFunc
+}
+
+// openStream sends GET request to the URL and returns the response body stream
+// or nil if the response has no content. Returned error can be retried only if
+// the request has failed without receiving the response.
+//
+// Content type of the response is checked only for text/event-stream.
+func openStream(ctx context.Context, c Client, url string, opt []RequestOption, accept string, mod func(req *Request)) (*responseStream, bool, error) {
+	req := c.NewRequest()
+	defer c.ReleaseRequest(req)
+
+	if err := req.SetRequestURL(url); err != nil {
+		return nil, false, err
+	}
+
+	req.apply(opt)
+	req.Header.SetMethod(fasthttp.MethodGet)
+
+	if len(req.Header.Peek(fasthttp.HeaderAccept)) == 0 {
+		req.Header.Set(fasthttp.HeaderAccept, accept)
+	}
+
+	if mod != nil {
+		mod(req)
+	}
+
+	// Closing the stream cancels the request so that the connection is closed
+	// without waiting for the pending read of the response body.
+	ctx, cancel := context.WithCancel(ctx)
+	WithContext(ctx).apply(req)
+
+	resp := c.NewResponse()
+	resp.StreamBody = true
+
+	release := func() {
+		c.ReleaseResponse(resp)
+		cancel()
+	}
+
+	if err := c.Do(req, resp); err != nil {
+		release()
+
+		return nil, true, err
+	}
+
+	if err := resp.Error(); err != nil {
+		release()
+
+		return nil, false, err
+	}
+
+	if resp.StatusCode() == fasthttp.StatusNoContent {
+		release()
+
+		return nil, false, nil
+	}
+
+	if accept == contentTypeEventStream {
+		contentType := string(resp.Header.ContentType())
+
+		if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != contentTypeEventStream {
+			release()
+
+			return nil, false, UnexpectedContentTypeError{ContentType: contentType}
+		}
+	}
+
+	return newResponseStream(ctx, cancel, c, resp), false, nil
+}
+
+// newResponseStream starts reading the response body in the background. The
+// response is released when the body is read to the end or the stream is closed.
+func newResponseStream(ctx context.Context, cancel context.CancelFunc, c Client, resp *Response) *responseStream {
+	pr, pw := io.Pipe()
+
+	go func() {
+		// Failed write to the closed pipe closes the connection if the body
+		// was not read to the end, otherwise the connection is reused. Response
+		// is released before the reader gets the end of the stream so that the
+		// connection is not closed by the stream closed right after that.
+		err := resp.BodyWriteTo(pw)
+		c.ReleaseResponse(resp)
+
+		_ = pw.CloseWithError(err)
+	}()
+
+	return &responseStream{
+		r: pr,
+		stop: context.AfterFunc(ctx, func() {
+			_ = pr.CloseWithError(context.Cause(ctx))
+		}),
+		cancel: cancel,
+	}
+}
+
+func (s *responseStream) scanner(split bufio.SplitFunc) *bufio.Scanner {
+	sc := bufio.NewScanner(s.r)
+	sc.Buffer(nil, maxStreamLineSize)
+	sc.Split(split)
+
+	return sc
+}
+
+// Close stops reading the stream and closes the connection unless the
+// response body has already been read to the end.
+func (s *responseStream) Close() {
+	s.stop()
+
+	_ = s.r.CloseWithError(errStreamClosed)
+
+	s.cancel()
+}
+
+// streamContext returns the context that aborts reading the stream when either
+// the request or the client context is canceled.
+func streamContext(c Client, opt []RequestOption) (context.Context, context.CancelFunc) {
+	req := &Request{}
+
+	for _, o := range opt {
+		if v, ok := o.(*requestContext); ok {
+			v.apply(req)
+		}
+	}
+
+	if cl, ok := c.(*client); ok {
+		return cl.requestContext(req)
+	}
+
+	if req.ctx != nil {
+		return context.WithCancel(req.ctx)
+	}
+
+	return context.WithCancel(context.Background())
+}
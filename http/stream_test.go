@@ -0,0 +1,266 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-quicktest/qt"
+	"github.com/valyala/fasthttp"
+)
+
+func TestStreamEvents(t *testing.T) {
+	var (
+		connections atomic.Int32
+		lastEventID atomic.Value
+	)
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		n := connections.Add(1)
+		lastEventID.Store(string(ctx.Request.Header.Peek(headerLastEventID)))
+
+		ctx.SetContentType("text/event-stream; charset=utf-8")
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			if n == 1 {
+				_, _ = w.WriteString(": comment\nretry: 10\n\nevent: greeting\ndata: hello\ndata:  world\nid: 1\n\n")
+				_, _ = w.WriteString("data: {\"value\":2}\r\nid: 2\r\n\r\ndata: incomplete")
+			} else {
+				_, _ = w.WriteString("data: 3\rid\r\r")
+			}
+
+			_ = w.Flush()
+		})
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	var events []Event
+
+	for ev, err := range StreamEvents(c, "http://localhost/events", EventStream{RetryDelay: time.Minute}) {
+		qt.Assert(t, qt.IsNil(err))
+
+		events = append(events, ev)
+
+		if len(events) == 3 {
+			break
+		}
+	}
+
+	qt.Check(t, qt.DeepEquals(events, []Event{
+		{ID: "1", Type: "greeting", Data: "hello\n world"},
+		{ID: "2", Type: "message", Data: `{"value":2}`},
+		{ID: "", Type: "message", Data: "3"},
+	}))
+	qt.Check(t, qt.Equals(connections.Load(), int32(2)))
+	qt.Check(t, qt.Equals(lastEventID.Load(), "2"))
+
+	var v struct {
+		Value int `json:"value"`
+	}
+
+	qt.Check(t, qt.IsNil(events[1].Decode(&v)))
+	qt.Check(t, qt.Equals(v.Value, 2))
+}
+
+func TestStreamEventsMaxReconnects(t *testing.T) {
+	var connections atomic.Int32
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		connections.Add(1)
+		ctx.SetContentType("text/event-stream")
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	events, err := collect(t, StreamEvents(c, "http://localhost/events", EventStream{RetryDelay: time.Millisecond, MaxReconnects: 2}))
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.HasLen(events, 0))
+	qt.Check(t, qt.Equals(connections.Load(), int32(3)))
+
+	connections.Store(0)
+
+	_, err = collect(t, StreamEvents(c, "http://localhost/events", EventStream{NoReconnect: true}))
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(connections.Load(), int32(1)))
+}
+
+func TestStreamEventsErrors(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		switch string(ctx.Path()) {
+		case "/error":
+			ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+		case "/json":
+			ctx.SetContentType("application/json")
+			ctx.SetBodyString("{}")
+		case "/empty":
+			ctx.SetStatusCode(fasthttp.StatusNoContent)
+		}
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	_, err := collect(t, StreamEvents(c, "http://localhost/error"))
+	qt.Check(t, qt.ErrorAs(err, new(ServerError)))
+
+	_, err = collect(t, StreamEvents(c, "http://localhost/json"))
+	qt.Check(t, qt.ErrorMatches(err, `unexpected response content type "application/json"`))
+
+	_, err = collect(t, StreamEvents(c, "http://localhost/empty"))
+	qt.Check(t, qt.IsNil(err))
+}
+
+func TestStreamEventsContextCanceled(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetContentType("text/event-stream")
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			_, _ = w.WriteString("data: 1\n\n")
+			_ = w.Flush()
+
+			<-done
+		})
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var events []Event
+
+	for ev, err := range StreamEvents(c, "http://localhost/events", WithContext(ctx)) {
+		if err != nil {
+			qt.Check(t, qt.ErrorIs(err, context.Canceled))
+
+			break
+		}
+
+		events = append(events, ev)
+
+		// Stream is blocked waiting for the next event.
+		time.AfterFunc(10*time.Millisecond, cancel)
+	}
+
+	qt.Check(t, qt.HasLen(events, 1))
+}
+
+func TestStreamNDJSON(t *testing.T) {
+	var requests atomic.Int32
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		requests.Add(1)
+
+		qt.Check(t, qt.Equals(string(ctx.Request.Header.Peek(fasthttp.HeaderAccept)), "application/x-ndjson"))
+
+		ctx.SetContentType("application/x-ndjson")
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			for _, line := range []string{`{"id":1}`, ``, `{"id":2}`, `{"id":3}`} {
+				_, _ = w.WriteString(line + "\n")
+				_ = w.Flush()
+			}
+		})
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	items, err := collect(t, StreamNDJSON[item](c, "http://localhost/items"))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.DeepEquals(items, []item{{ID: 1}, {ID: 2}, {ID: 3}}))
+
+	// Connection of the stream that was not read to the end is not reused.
+	for v, err := range StreamNDJSON[item](c, "http://localhost/items") {
+		qt.Assert(t, qt.IsNil(err))
+		qt.Check(t, qt.Equals(v.ID, 1))
+
+		break
+	}
+
+	items, err = collect(t, StreamNDJSON[item](c, "http://localhost/items"))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.HasLen(items, 3))
+	qt.Check(t, qt.Equals(requests.Load(), int32(3)))
+}
+
+func TestStreamCloseIdle(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetContentType("application/x-ndjson")
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			_, _ = w.WriteString("{\"id\":1}\n")
+			_ = w.Flush()
+
+			// Keep the stream open without sending anything.
+			<-done
+		})
+	}
+	s.Start()
+	defer s.Stop()
+
+	conn := &testClosedConn{closed: make(chan struct{})}
+
+	dial := s.DialContext()
+
+	c := NewClient(DialContextFunc(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var err error
+		conn.Conn, err = dial(ctx, network, addr)
+
+		return conn, err
+	}))
+
+	for v, err := range StreamNDJSON[map[string]int](c, "http://localhost/items") {
+		qt.Assert(t, qt.IsNil(err))
+		qt.Check(t, qt.Equals(v["id"], 1))
+
+		break
+	}
+
+	// Closed stream does not wait for the pending read of the idle stream.
+	select {
+	case <-conn.closed:
+	case <-time.After(200 * time.Millisecond):
+		t.Error("connection was not closed")
+	}
+}
+
+func TestStreamNDJSONDecodeError(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetContentType("application/x-ndjson")
+		ctx.SetBodyString("{\"id\":1}\nnot json\n")
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	items, err := collect(t, StreamNDJSON[map[string]int](c, "http://localhost/items"))
+	qt.Check(t, qt.DeepEquals(items, []map[string]int{{"id": 1}}))
+	qt.Check(t, qt.IsNotNil(err))
+}
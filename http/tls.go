@@ -0,0 +1,312 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"azugo.io/core/cert"
+)
+
+const defaultMutualTLSReloadInterval = 30 * time.Second
+
+// MutualTLS configures the HTTP client TLS settings from PEM encoded certificate files.
+//
+// Client certificate and CA certificates are loaded using the cert package and are
+// reloaded when the files change.
+type MutualTLS struct {
+	// CertFile is the path to PEM encoded client certificate file. File can also contain private key.
+	CertFile string
+	// KeyFile is the path to PEM encoded client private key file.
+	KeyFile string
+	// Password to decrypt the encrypted client private key.
+	Password []byte
+	// CAFile is the path to PEM encoded CA certificates bundle file used to verify server certificate.
+	// If not set, system CA certificates are used.
+	CAFile string
+	// ServerName is used to verify the server certificate hostname.
+	ServerName string
+	// InsecureSkipVerify disables server certificate verification.
+	InsecureSkipVerify bool
+	// ReloadInterval is the minimum interval between checks for file changes (defaults to 30s).
+	// Use negative value to disable reloading.
+	ReloadInterval time.Duration
+}
+
+// apply loads the certificate files once when the option is applied. If files can
+// not be loaded, all client requests fail with the load error.
+func (m MutualTLS) apply(o *options) {
+	files := newTLSFiles(&m)
+
+	o.tlsFiles, o.tlsError = files, files.reload(true)
+	o.TLSConfig = files.config()
+}
+
+// TLSConfig loads the certificate files and returns TLS configuration that reloads them on change.
+func (m MutualTLS) TLSConfig() (*tls.Config, error) {
+	files := newTLSFiles(&m)
+
+	if err := files.reload(true); err != nil {
+		return nil, err
+	}
+
+	return files.config(), nil
+}
+
+// tlsFilesOption sets the client TLS configuration from already loaded certificate files.
+type tlsFilesOption struct {
+	files *tlsFiles
+}
+
+func (t tlsFilesOption) apply(o *options) {
+	o.tlsFiles, o.tlsError = t.files, nil
+	o.TLSConfig = t.files.config()
+}
+
+type tlsFile struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// changed reports if the file has changed since it was last loaded.
+func (f *tlsFile) changed() (bool, error) {
+	if f.path == "" {
+		return false, nil
+	}
+
+	st, err := os.Stat(f.path)
+	if err != nil {
+		return false, err
+	}
+
+	if st.ModTime().Equal(f.modTime) && st.Size() == f.size {
+		return false, nil
+	}
+
+	f.modTime, f.size = st.ModTime(), st.Size()
+
+	return true, nil
+}
+
+// tlsFiles holds certificates loaded from files.
+type tlsFiles struct {
+	settings *MutualTLS
+
+	lock      sync.Mutex
+	checked   time.Time
+	certFile  tlsFile
+	keyFile   tlsFile
+	caFile    tlsFile
+	cert      *tls.Certificate
+	pool      *x509.CertPool
+	loadError error
+}
+
+func newTLSFiles(m *MutualTLS) *tlsFiles {
+	c := *m
+
+	if c.ReloadInterval == 0 {
+		c.ReloadInterval = defaultMutualTLSReloadInterval
+	}
+
+	return &tlsFiles{
+		settings: &c,
+		certFile: tlsFile{path: c.CertFile},
+		keyFile:  tlsFile{path: c.KeyFile},
+		caFile:   tlsFile{path: c.CAFile},
+	}
+}
+
+// reload checks files for changes and loads them if needed.
+func (f *tlsFiles) reload(force bool) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	now := time.Now()
+
+	if !force && !f.checked.IsZero() && (f.settings.ReloadInterval < 0 || now.Sub(f.checked) < f.settings.ReloadInterval) {
+		return f.loadError
+	}
+
+	f.checked = now
+
+	certChanged, err := f.certFile.changed()
+	if err != nil {
+		return f.failed(fmt.Errorf("failed to load client certificate: %w", err))
+	}
+
+	keyChanged, err := f.keyFile.changed()
+	if err != nil {
+		return f.failed(fmt.Errorf("failed to load client private key: %w", err))
+	}
+
+	if certChanged || keyChanged {
+		crt, err := f.loadCertificate()
+		if err != nil {
+			return f.failed(fmt.Errorf("failed to load client certificate: %w", err))
+		}
+
+		f.cert = crt
+	}
+
+	caChanged, err := f.caFile.changed()
+	if err != nil {
+		return f.failed(fmt.Errorf("failed to load CA certificates: %w", err))
+	}
+
+	if caChanged {
+		pool, err := cert.LoadCertPoolFromFile(f.caFile.path)
+		if err != nil {
+			return f.failed(fmt.Errorf("failed to load CA certificates: %w", err))
+		}
+
+		f.pool = pool
+	}
+
+	f.loadError = nil
+
+	return nil
+}
+
+// failed records the load error and forces files to be loaded again on next check.
+func (f *tlsFiles) failed(err error) error {
+	f.certFile.modTime, f.keyFile.modTime, f.caFile.modTime = time.Time{}, time.Time{}, time.Time{}
+	f.loadError = err
+
+	return err
+}
+
+func (f *tlsFiles) loadCertificate() (*tls.Certificate, error) {
+	var opts []cert.Option
+	if len(f.settings.Password) > 0 {
+		opts = append(opts, cert.Password(f.settings.Password))
+	}
+
+	// Read certificate file as is to keep the full certificate chain.
+	crt, err := os.ReadFile(f.certFile.path)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPath := f.keyFile.path
+	if keyPath == "" {
+		keyPath = f.certFile.path
+	}
+
+	_, key, err := cert.LoadPEMFromFile(keyPath, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) == 0 {
+		return nil, errors.New("private key not found")
+	}
+
+	return cert.LoadTLSCertificate(crt, key)
+}
+
+// config returns TLS configuration that uses the loaded certificates.
+func (f *tlsFiles) config() *tls.Config {
+	conf := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         f.settings.ServerName,
+		InsecureSkipVerify: f.settings.InsecureSkipVerify, //nolint:gosec
+	}
+
+	if f.settings.CertFile != "" {
+		conf.GetClientCertificate = f.clientCertificate
+	}
+
+	if f.settings.CAFile != "" && !f.settings.InsecureSkipVerify {
+		// Server certificate is verified manually against the current CA pool,
+		// so that CA certificates can be reloaded without creating a new client.
+		conf.InsecureSkipVerify = true //nolint:gosec
+		conf.VerifyConnection = func(cs tls.ConnectionState) error {
+			return f.verifyConnection(cs, "")
+		}
+	}
+
+	return conf
+}
+
+// hostConfig returns TLS configuration for connections to the address that
+// verifies the server certificate against the address host if server name is
+// not set. Server name is not sent by the TLS client for IP addresses.
+func (f *tlsFiles) hostConfig(conf *tls.Config, addr string) *tls.Config {
+	if conf == nil || conf.VerifyConnection == nil {
+		return conf
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	conf = conf.Clone()
+	conf.VerifyConnection = func(cs tls.ConnectionState) error {
+		return f.verifyConnection(cs, host)
+	}
+
+	return conf
+}
+
+func (f *tlsFiles) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if err := f.reload(false); err != nil {
+		return nil, err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.cert, nil
+}
+
+// verifyConnection verifies the server certificate against the current CA pool
+// and the configured server name, the server name sent by the client or the
+// host the connection was made to, in that order.
+func (f *tlsFiles) verifyConnection(cs tls.ConnectionState, host string) error {
+	if err := f.reload(false); err != nil {
+		return err
+	}
+
+	name := f.settings.ServerName
+	if name == "" {
+		name = cs.ServerName
+	}
+
+	if name == "" {
+		name = host
+	}
+
+	if name == "" {
+		return errors.New("tls: server name is required to verify server certificate")
+	}
+
+	f.lock.Lock()
+	pool := f.pool
+	f.lock.Unlock()
+
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("tls: server did not provide a certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         pool,
+		DNSName:       name,
+		Intermediates: x509.NewCertPool(),
+	}
+
+	for _, crt := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(crt)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(opts)
+
+	return err
+}
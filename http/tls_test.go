@@ -0,0 +1,249 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"azugo.io/core/cert"
+
+	"github.com/go-quicktest/qt"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	qt.Assert(t, qt.IsNil(err))
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	qt.Assert(t, qt.IsNil(err))
+
+	crt, err := x509.ParseCertificate(der)
+	qt.Assert(t, qt.IsNil(err))
+
+	p, _, err := cert.DERBytesToPEMBlocks(der, nil)
+	qt.Assert(t, qt.IsNil(err))
+
+	return &testCA{cert: crt, key: key, pem: p}
+}
+
+// issue returns PEM encoded certificate and private key signed by the CA.
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	qt.Assert(t, qt.IsNil(err))
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	qt.Assert(t, qt.IsNil(err))
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	if ip := net.ParseIP(cn); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{cn}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	qt.Assert(t, qt.IsNil(err))
+
+	crt, priv, err := cert.DERBytesToPEMBlocks(der, key)
+	qt.Assert(t, qt.IsNil(err))
+
+	return crt, priv
+}
+
+// newTestTLSServer starts the TLS server with certificate for the host signed by the CA
+// that responds with the client certificate common name.
+func newTestTLSServer(t *testing.T, ca *testCA, host string) DialContextFunc {
+	t.Helper()
+
+	serverCrt, serverKey := ca.issue(t, host, x509.ExtKeyUsageServerAuth)
+	serverCert, err := cert.LoadTLSCertificate(serverCrt, serverKey)
+	qt.Assert(t, qt.IsNil(err))
+
+	clientCAs, err := cert.LoadCertPool(ca.pem)
+	qt.Assert(t, qt.IsNil(err))
+
+	ln := fasthttputil.NewInmemoryListener()
+	t.Cleanup(func() { _ = ln.Close() })
+
+	server := &fasthttp.Server{
+		Logger: log.New(io.Discard, "", 0),
+		Handler: func(ctx *fasthttp.RequestCtx) {
+			ctx.SetConnectionClose()
+
+			state := ctx.TLSConnectionState()
+			if state == nil || len(state.PeerCertificates) == 0 {
+				ctx.SetStatusCode(fasthttp.StatusUnauthorized)
+				return
+			}
+
+			ctx.SetBodyString(state.PeerCertificates[0].Subject.CommonName)
+		},
+	}
+
+	go func() {
+		_ = server.Serve(tls.NewListener(ln, &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{*serverCert},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    clientCAs,
+		}))
+	}()
+
+	return func(context.Context, string, string) (net.Conn, error) {
+		return ln.Dial()
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client.key")
+
+	qt.Assert(t, qt.IsNil(os.WriteFile(caFile, ca.pem, 0o600)))
+
+	crt, key := ca.issue(t, "client1", x509.ExtKeyUsageClientAuth)
+	qt.Assert(t, qt.IsNil(os.WriteFile(certFile, crt, 0o600)))
+	qt.Assert(t, qt.IsNil(os.WriteFile(keyFile, key, 0o600)))
+
+	dial := newTestTLSServer(t, ca, "localhost")
+
+	mtls := MutualTLS{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		CAFile:         caFile,
+		ReloadInterval: time.Nanosecond,
+	}
+
+	c := NewClient(dial, mtls)
+
+	body, err := c.Get("https://localhost/")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), "client1"))
+
+	// Rotate client certificate.
+	crt, key = ca.issue(t, "client2", x509.ExtKeyUsageClientAuth)
+	qt.Assert(t, qt.IsNil(os.WriteFile(keyFile, key, 0o600)))
+	qt.Assert(t, qt.IsNil(os.WriteFile(certFile, crt, 0o600)))
+
+	body, err = c.Get("https://localhost/")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), "client2"))
+
+	// Server certificate not signed by configured CA is rejected.
+	other := newTestCA(t)
+	qt.Assert(t, qt.IsNil(os.WriteFile(caFile, other.pem, 0o600)))
+
+	_, err = c.Get("https://localhost/")
+	qt.Check(t, qt.IsNotNil(err))
+}
+
+func TestMutualTLSServerName(t *testing.T) {
+	ca := newTestCA(t)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "client.pem")
+
+	qt.Assert(t, qt.IsNil(os.WriteFile(caFile, ca.pem, 0o600)))
+
+	crt, key := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	qt.Assert(t, qt.IsNil(os.WriteFile(certFile, append(crt, key...), 0o600)))
+
+	mtls := MutualTLS{
+		CertFile: certFile,
+		CAFile:   caFile,
+	}
+
+	tests := []struct {
+		name       string
+		host       string
+		url        string
+		serverName string
+		valid      bool
+	}{
+		{name: "host", host: "localhost", url: "https://localhost/", valid: true},
+		{name: "wrong host", host: "other.example", url: "https://localhost/"},
+		{name: "ip", host: "127.0.0.1", url: "https://127.0.0.1/", valid: true},
+		{name: "wrong ip", host: "localhost", url: "https://127.0.0.1/"},
+		{name: "server name", host: "localhost", url: "https://127.0.0.1/", serverName: "localhost", valid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mtls
+			m.ServerName = tt.serverName
+
+			body, err := NewClient(newTestTLSServer(t, ca, tt.host), m).Get(tt.url)
+			if !tt.valid {
+				qt.Check(t, qt.IsNotNil(err))
+
+				return
+			}
+
+			qt.Assert(t, qt.IsNil(err))
+			qt.Check(t, qt.Equals(string(body), "client"))
+		})
+	}
+}
+
+func TestMutualTLSInvalidFiles(t *testing.T) {
+	_, err := MutualTLS{CertFile: filepath.Join(t.TempDir(), "missing.pem")}.TLSConfig()
+	qt.Check(t, qt.IsNotNil(err))
+
+	// Files are loaded when the client is created.
+	_, err = NewClient(MutualTLS{CertFile: filepath.Join(t.TempDir(), "missing.pem")}).Get("https://localhost/")
+	qt.Check(t, qt.ErrorMatches(err, "failed to load client certificate: .*"))
+
+	_, err = NamedClient{
+		BaseURL: "https://localhost",
+		TLS: NamedClientTLS{
+			CA: filepath.Join(t.TempDir(), "missing.pem"),
+		},
+	}.Options()
+	qt.Check(t, qt.IsNotNil(err))
+}
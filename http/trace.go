@@ -0,0 +1,38 @@
+package http
+
+import (
+	"context"
+
+	"azugo.io/core/trace"
+)
+
+// propagate sets W3C trace context and request ID headers from the context
+// and returns the context with the child span of the request.
+//
+// If context does not contain span, the new trace is started. Headers already
+// set on the request are not overwritten.
+func propagate(ctx context.Context, req *Request) context.Context {
+	if len(req.Header.Peek(trace.HeaderTraceParent)) == 0 {
+		var sc trace.SpanContext
+
+		if parent, ok := trace.SpanFromContext(ctx); ok {
+			sc = parent.Child()
+		} else {
+			sc = trace.NewSpanContext()
+		}
+
+		ctx = trace.ContextWithSpan(ctx, sc)
+
+		req.Header.Set(trace.HeaderTraceParent, sc.TraceParent())
+
+		if sc.TraceState != "" {
+			req.Header.Set(trace.HeaderTraceState, sc.TraceState)
+		}
+	}
+
+	if id, ok := trace.RequestIDFromContext(ctx); ok && len(req.Header.Peek(trace.HeaderRequestID)) == 0 {
+		req.Header.Set(trace.HeaderRequestID, id)
+	}
+
+	return ctx
+}
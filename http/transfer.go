@@ -0,0 +1,546 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ErrDownloadNotResumable is returned when the interrupted download can not be
+// resumed because the server has responded with the whole content.
+var ErrDownloadNotResumable = errors.New("download can not be resumed")
+
+// Transfer configures streaming uploads and downloads.
+//
+// Transfer is a request option that has effect only when passed to Upload,
+// PostMultipartFormStream, Download or DownloadFile.
+type Transfer struct {
+	// Progress is called with the number of bytes transferred so far and the
+	// total number of bytes or -1 if it is unknown.
+	Progress func(transferred, total int64)
+	// Checksum is the hash used to verify the downloaded content.
+	Checksum hash.Hash
+	// Sum is the expected checksum of the downloaded content.
+	Sum []byte
+	// MaxResumes is the maximum number of times the interrupted download is
+	// resumed with Range request (defaults to 0 meaning no resume).
+	MaxResumes int
+}
+
+func (Transfer) apply(*Request) {}
+
+func transferOptions(opt []RequestOption) Transfer {
+	var t Transfer
+
+	for _, o := range opt {
+		if v, ok := o.(Transfer); ok {
+			t = v
+		}
+	}
+
+	return t
+}
+
+// ChecksumMismatchError is returned when the checksum of the downloaded content does not match the expected one.
+type ChecksumMismatchError struct {
+	// Expected is the expected checksum.
+	Expected []byte
+	// Actual is the checksum of the downloaded content.
+	Actual []byte
+}
+
+func (e ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch: expected %x, got %x", e.Expected, e.Actual)
+}
+
+// FormFile is a multipart form file part with content read from the reader.
+type FormFile struct {
+	// Field is the form field name.
+	Field string
+	// Name is the file name.
+	Name string
+	// ContentType is the content type of the file (defaults to application/octet-stream).
+	ContentType string
+	// Reader is the file content.
+	Reader io.Reader
+}
+
+// MultipartForm is a multipart form with file parts streamed from readers.
+type MultipartForm struct {
+	// Value contains form field values.
+	Value map[string][]string
+	// File contains file parts in the order they are sent.
+	File []FormFile
+}
+
+// Upload performs a PUT request to the specified URL streaming the body from the reader.
+//
+// If size is negative the body is sent with chunked transfer encoding. If body
+// implements io.Closer it is closed after the request is sent.
+func (c client) Upload(url string, body io.Reader, size int, opt ...RequestOption) ([]byte, error) {
+	req := c.NewRequest()
+	if err := req.SetRequestURL(url); err != nil {
+		c.ReleaseRequest(req)
+
+		return nil, err
+	}
+
+	req.apply(opt)
+
+	req.Header.SetMethod(fasthttp.MethodPut)
+
+	if len(req.Header.ContentType()) == 0 {
+		req.Header.SetContentType("application/octet-stream")
+	}
+
+	req.SetBodyStream(newProgressReader(body, int64(size), transferOptions(opt).Progress), size)
+
+	return c.call(req)
+}
+
+// PostMultipartFormStream performs a POST request to the specified URL with
+// multipart form values and files streamed from their readers instead of
+// buffering the whole form in memory.
+//
+// Form is sent with chunked transfer encoding.
+func (c client) PostMultipartFormStream(url string, form *MultipartForm, opt ...RequestOption) ([]byte, error) {
+	req := c.NewRequest()
+	if err := req.SetRequestURL(url); err != nil {
+		c.ReleaseRequest(req)
+
+		return nil, err
+	}
+
+	req.apply(opt)
+
+	req.Header.SetMethod(fasthttp.MethodPost)
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+
+	mw := multipart.NewWriter(pw)
+
+	req.Header.SetMultipartFormBoundary(mw.Boundary())
+	req.SetBodyStream(newProgressReader(pr, -1, transferOptions(opt).Progress), -1)
+
+	go func() {
+		_ = pw.CloseWithError(writeMultipartForm(mw, form))
+	}()
+
+	return c.call(req)
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func writeMultipartForm(mw *multipart.Writer, form *MultipartForm) error {
+	if form == nil {
+		return mw.Close()
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(form.Value)) {
+		for _, v := range form.Value[k] {
+			if err := mw.WriteField(k, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, f := range form.File {
+		contentType := f.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(f.Field), quoteEscaper.Replace(f.Name)))
+		h.Set(fasthttp.HeaderContentType, contentType)
+
+		w, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(w, f.Reader); err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+// Download performs a GET request to the specified URL and writes the response body to w.
+//
+// Response body is streamed without loading it into memory. If the download is
+// interrupted it is resumed from the last received byte with Range request up
+// to Transfer.MaxResumes times.
+func (c client) Download(url string, w io.Writer, opt ...RequestOption) error {
+	return c.download(url, w, 0, "", nil, nil, opt)
+}
+
+// DownloadFile performs a GET request to the specified URL and writes the response body to the file.
+//
+// If the file already exists download is resumed from its current size with
+// Range request and If-Range header set to the file modification time. File
+// modification time is set to the Last-Modified time of the downloaded content,
+// so the download is resumed only if the content has not changed since then.
+// Otherwise or if the server does not support range requests the file is written
+// from the beginning. File is removed if its checksum does not match.
+func (c client) DownloadFile(url, path string, opt ...RequestOption) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	var validator string
+	if offset > 0 {
+		validator = string(fasthttp.AppendHTTPDate(nil, fi.ModTime()))
+	}
+
+	if t := transferOptions(opt); offset > 0 && t.Checksum != nil {
+		// Include already downloaded content in the checksum.
+		if _, err := io.Copy(t.Checksum, io.NewSectionReader(f, 0, offset)); err != nil {
+			_ = f.Close()
+
+			return err
+		}
+	}
+
+	var modified time.Time
+
+	err = c.download(url, f, offset, validator, func() error {
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+
+		_, err := f.Seek(0, io.SeekStart)
+
+		return err
+	}, func(t time.Time) {
+		modified = t
+	}, opt)
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if errors.As(err, new(ChecksumMismatchError)) {
+		_ = os.Remove(path)
+
+		return err
+	}
+
+	if !modified.IsZero() {
+		if cerr := os.Chtimes(path, time.Time{}, modified); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// download writes the content starting from the offset to w. Validator is
+// sent in If-Range header of the range request. Reset is called to write the
+// content from the beginning if the server responds with the whole content to
+// the range request. Modified is called with the Last-Modified time of the content.
+func (c client) download(url string, w io.Writer, offset int64, validator string, reset func() error, modified func(time.Time), opt []RequestOption) error {
+	t := transferOptions(opt)
+
+	ctx, cancel := streamContext(c, opt)
+	defer cancel()
+
+	for resumes := 0; ; resumes++ {
+		// Closing the stream cancels the request so that the connection is closed
+		// without waiting for the pending read of the response body.
+		rctx, rcancel := context.WithCancel(ctx)
+
+		resp, err := c.downloadRequest(rctx, url, offset, validator, opt)
+		if err != nil {
+			rcancel()
+
+			return err
+		}
+
+		total, restart, complete, err := downloadResponse(resp, offset)
+		if err != nil || complete {
+			c.ReleaseResponse(resp)
+			rcancel()
+
+			if err != nil {
+				return err
+			}
+
+			break
+		}
+
+		if restart {
+			if reset == nil {
+				c.ReleaseResponse(resp)
+				rcancel()
+
+				return ErrDownloadNotResumable
+			}
+
+			if err := reset(); err != nil {
+				c.ReleaseResponse(resp)
+				rcancel()
+
+				return err
+			}
+
+			offset = 0
+
+			if t.Checksum != nil {
+				t.Checksum.Reset()
+			}
+		}
+
+		if resp.StatusCode() != fasthttp.StatusPartialContent {
+			validator = rangeValidator(resp)
+
+			if lm, err := fasthttp.ParseHTTPDate(resp.Header.Peek(fasthttp.HeaderLastModified)); err == nil && modified != nil {
+				modified(lm)
+			}
+		}
+
+		s := newResponseStream(rctx, rcancel, c, resp)
+		pw := &progressWriter{w: w, hash: t.Checksum, n: offset, total: total, progress: t.Progress}
+
+		_, err = io.Copy(pw, s.r)
+		s.Close()
+
+		offset = pw.n
+
+		if err == nil && total >= 0 && offset < total {
+			err = io.ErrUnexpectedEOF
+		}
+
+		switch {
+		case pw.err != nil:
+			return pw.err
+		case err == nil:
+			return t.verify()
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case resumes >= t.MaxResumes:
+			return err
+		}
+	}
+
+	return t.verify()
+}
+
+// downloadRequest sends the download request for the content starting from the offset.
+func (c client) downloadRequest(ctx context.Context, url string, offset int64, validator string, opt []RequestOption) (*Response, error) {
+	req := c.NewRequest()
+	defer c.ReleaseRequest(req)
+
+	if err := req.SetRequestURL(url); err != nil {
+		return nil, err
+	}
+
+	req.apply(opt)
+	WithContext(ctx).apply(req)
+	req.Header.SetMethod(fasthttp.MethodGet)
+
+	if offset > 0 {
+		req.Header.SetByteRange(int(offset), -1)
+
+		if validator != "" {
+			req.Header.Set(fasthttp.HeaderIfRange, validator)
+		}
+	}
+
+	resp := c.NewResponse()
+	resp.StreamBody = true
+
+	if err := c.Do(req, resp); err != nil {
+		c.ReleaseResponse(resp)
+
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// downloadResponse checks the download response and returns the total size of
+// the content or -1 if it is unknown. It reports if the whole content is sent
+// instead of the requested range and if the content has already been downloaded.
+func downloadResponse(resp *Response, offset int64) (int64, bool, bool, error) {
+	switch resp.StatusCode() {
+	case fasthttp.StatusRequestedRangeNotSatisfiable:
+		if _, total, ok := parseContentRange(string(resp.Header.Peek(fasthttp.HeaderContentRange))); ok && offset > 0 && total == offset {
+			return total, false, true, nil
+		}
+	case fasthttp.StatusPartialContent:
+		start, total, ok := parseContentRange(string(resp.Header.Peek(fasthttp.HeaderContentRange)))
+		if !ok || start != offset {
+			return 0, false, false, fmt.Errorf("unexpected content range %q", resp.Header.Peek(fasthttp.HeaderContentRange))
+		}
+
+		return total, false, false, nil
+	}
+
+	if err := resp.Error(); err != nil {
+		return 0, false, false, err
+	}
+
+	return int64(max(resp.Header.ContentLength(), -1)), offset > 0, false, nil
+}
+
+// rangeValidator returns the strong ETag or Last-Modified value of the response to be used in If-Range header.
+func rangeValidator(resp *Response) string {
+	if etag := resp.Header.Peek(fasthttp.HeaderETag); len(etag) > 0 && !bytes.HasPrefix(etag, []byte("W/")) {
+		return string(etag)
+	}
+
+	return string(resp.Header.Peek(fasthttp.HeaderLastModified))
+}
+
+// parseContentRange parses Content-Range header value in bytes unit and returns
+// the range start position and the total size or -1 if it is unknown.
+func parseContentRange(v string) (int64, int64, bool) {
+	v, ok := strings.CutPrefix(v, "bytes ")
+	if !ok {
+		return 0, 0, false
+	}
+
+	rng, size, ok := strings.Cut(v, "/")
+	if !ok {
+		return 0, 0, false
+	}
+
+	total := int64(-1)
+
+	if size != "*" {
+		n, err := strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+
+		total = n
+	}
+
+	if rng == "*" {
+		return -1, total, true
+	}
+
+	first, _, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return start, total, true
+}
+
+// verify compares the checksum of the downloaded content with the expected one.
+func (t Transfer) verify() error {
+	if t.Checksum == nil || len(t.Sum) == 0 {
+		return nil
+	}
+
+	if sum := t.Checksum.Sum(nil); !bytes.Equal(sum, t.Sum) {
+		return ChecksumMismatchError{Expected: t.Sum, Actual: sum}
+	}
+
+	return nil
+}
+
+// progressReader reports the number of bytes read.
+type progressReader struct {
+	r        io.Reader
+	n        int64
+	total    int64
+	progress func(transferred, total int64)
+}
+
+func newProgressReader(r io.Reader, total int64, progress func(transferred, total int64)) io.Reader {
+	if progress == nil {
+		return r
+	}
+
+	return &progressReader{r: r, total: max(total, -1), progress: progress}
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.n += int64(n)
+		r.progress(r.n, r.total)
+	}
+
+	return n, err
+}
+
+// Close closes the underlying reader if it implements io.Closer.
+func (r *progressReader) Close() error {
+	if rc, ok := r.r.(io.Closer); ok {
+		return rc.Close()
+	}
+
+	return nil
+}
+
+// progressWriter writes to the destination updating the checksum and reporting
+// the number of bytes written. Destination write error is kept so that it can
+// be distinguished from the response read error.
+type progressWriter struct {
+	w        io.Writer
+	hash     hash.Hash
+	n        int64
+	total    int64
+	progress func(transferred, total int64)
+	err      error
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if err != nil {
+		w.err = err
+	}
+
+	if n > 0 {
+		if w.hash != nil {
+			_, _ = w.hash.Write(p[:n])
+		}
+
+		w.n += int64(n)
+
+		if w.progress != nil {
+			w.progress(w.n, w.total)
+		}
+	}
+
+	return n, err
+}
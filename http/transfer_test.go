@@ -0,0 +1,354 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-quicktest/qt"
+	"github.com/valyala/fasthttp"
+)
+
+var (
+	transferContent  = bytes.Repeat([]byte("0123456789"), 10000)
+	transferModified = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+)
+
+type transferTestServer struct {
+	lock      sync.Mutex
+	ranges    []string
+	interrupt atomic.Int32
+	noRanges  bool
+	ifRange   bool
+}
+
+func newTransferTestServer(t *testing.T, ts *transferTestServer) Client {
+	t.Helper()
+
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		rng := string(ctx.Request.Header.Peek(fasthttp.HeaderRange))
+
+		ts.lock.Lock()
+		ts.ranges = append(ts.ranges, rng)
+		ts.lock.Unlock()
+
+		ctx.Response.Header.Set(fasthttp.HeaderETag, `"v1"`)
+		ctx.Response.Header.Set(fasthttp.HeaderLastModified, string(fasthttp.AppendHTTPDate(nil, transferModified)))
+
+		// Range is ignored if the content has changed.
+		if v := string(ctx.Request.Header.Peek(fasthttp.HeaderIfRange)); ts.ifRange && v != "" &&
+			v != `"v1"` && v != string(ctx.Response.Header.Peek(fasthttp.HeaderLastModified)) {
+			rng = ""
+		}
+
+		start := 0
+		if v, ok := strings.CutPrefix(rng, "bytes="); ok && !ts.noRanges {
+			start, _ = strconv.Atoi(strings.TrimSuffix(v, "-"))
+
+			if start >= len(transferContent) {
+				ctx.Response.Header.Set(fasthttp.HeaderContentRange, fmt.Sprintf("bytes */%d", len(transferContent)))
+				ctx.SetStatusCode(fasthttp.StatusRequestedRangeNotSatisfiable)
+
+				return
+			}
+
+			ctx.Response.Header.Set(fasthttp.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, len(transferContent)-1, len(transferContent)))
+			ctx.SetStatusCode(fasthttp.StatusPartialContent)
+		}
+
+		body := transferContent[start:]
+
+		if ts.interrupt.Add(-1) >= 0 {
+			// Send only part of the declared content length and close the connection.
+			ctx.Response.Header.SetContentLength(len(body))
+
+			header := append([]byte(nil), ctx.Response.Header.Header()...)
+
+			ctx.HijackSetNoResponse(true)
+			ctx.Hijack(func(conn net.Conn) {
+				_, _ = conn.Write(header)
+				_, _ = conn.Write(body[:len(body)/2])
+			})
+
+			return
+		}
+
+		ctx.SetBody(body)
+	}
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	return NewClient(s.DialContext())
+}
+
+func TestUpload(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		qt.Check(t, qt.Equals(string(ctx.Method()), fasthttp.MethodPut))
+		qt.Check(t, qt.Equals(string(ctx.Request.Header.ContentType()), "application/octet-stream"))
+
+		ctx.SetBodyString(strconv.Itoa(len(ctx.Request.Body())) + " " + strconv.FormatBool(ctx.Request.Header.ContentLength() == -1))
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	var transferred, total int64
+
+	progress := Transfer{Progress: func(n, t int64) {
+		transferred, total = n, t
+	}}
+
+	resp, err := c.Upload("http://localhost/upload", bytes.NewReader(transferContent), len(transferContent), progress)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(resp), "100000 false"))
+	qt.Check(t, qt.Equals(transferred, int64(len(transferContent))))
+	qt.Check(t, qt.Equals(total, int64(len(transferContent))))
+
+	resp, err = c.Upload("http://localhost/upload", bytes.NewReader(transferContent), -1, progress)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(resp), "100000 true"))
+	qt.Check(t, qt.Equals(total, int64(-1)))
+}
+
+func TestPostMultipartFormStream(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		boundary := string(ctx.Request.Header.MultipartFormBoundary())
+
+		form, err := multipart.NewReader(bytes.NewReader(ctx.Request.Body()), boundary).ReadForm(1 << 20)
+		qt.Assert(t, qt.IsNil(err))
+
+		qt.Check(t, qt.DeepEquals(form.Value, map[string][]string{"name": {"artifact"}, "tags": {"a", "b"}}))
+		qt.Check(t, qt.HasLen(form.File["file"], 2))
+
+		for _, fh := range form.File["file"] {
+			f, err := fh.Open()
+			qt.Assert(t, qt.IsNil(err))
+
+			data, err := io.ReadAll(f)
+			qt.Check(t, qt.IsNil(err))
+			qt.Check(t, qt.IsNil(f.Close()))
+
+			_, _ = fmt.Fprintf(ctx, "%s:%s:%d;", fh.Filename, fh.Header.Get(fasthttp.HeaderContentType), len(data))
+		}
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	resp, err := c.PostMultipartFormStream("http://localhost/upload", &MultipartForm{
+		Value: map[string][]string{
+			"name": {"artifact"},
+			"tags": {"a", "b"},
+		},
+		File: []FormFile{
+			{Field: "file", Name: "data.bin", Reader: bytes.NewReader(transferContent)},
+			{Field: "file", Name: `report "1".txt`, ContentType: "text/plain", Reader: strings.NewReader("report")},
+		},
+	})
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(resp), `data.bin:application/octet-stream:100000;report "1".txt:text/plain:6;`))
+}
+
+func TestPostMultipartFormStreamReaderError(t *testing.T) {
+	s := newTestHttpServer()
+	s.Handler = func(ctx *fasthttp.RequestCtx) {
+		_, _ = ctx.MultipartForm()
+	}
+	s.Start()
+	defer s.Stop()
+
+	c := NewClient(s.DialContext())
+
+	_, err := c.PostMultipartFormStream("http://localhost/upload", &MultipartForm{
+		File: []FormFile{
+			{Field: "file", Name: "data.bin", Reader: io.MultiReader(strings.NewReader("data"), iotestErrReader{})},
+		},
+	})
+	qt.Check(t, qt.IsNotNil(err))
+}
+
+type iotestErrReader struct{}
+
+func (iotestErrReader) Read([]byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestDownload(t *testing.T) {
+	ts := &transferTestServer{}
+	c := newTransferTestServer(t, ts)
+
+	var (
+		buf         bytes.Buffer
+		transferred int64
+	)
+
+	h := sha256.New()
+	sum := sha256.Sum256(transferContent)
+
+	err := c.Download("http://localhost/file", &buf, Transfer{
+		Checksum: h,
+		Sum:      sum[:],
+		Progress: func(n, total int64) {
+			transferred = n
+
+			qt.Check(t, qt.Equals(total, int64(len(transferContent))))
+		},
+	})
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.IsTrue(bytes.Equal(buf.Bytes(), transferContent)))
+	qt.Check(t, qt.Equals(transferred, int64(len(transferContent))))
+}
+
+func TestDownloadResume(t *testing.T) {
+	ts := &transferTestServer{}
+	ts.interrupt.Store(2)
+
+	c := newTransferTestServer(t, ts)
+
+	var buf bytes.Buffer
+
+	err := c.Download("http://localhost/file", &buf, Transfer{MaxResumes: 2})
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.IsTrue(bytes.Equal(buf.Bytes(), transferContent)))
+	qt.Check(t, qt.DeepEquals(ts.ranges, []string{"", "bytes=50000-", "bytes=75000-"}))
+
+	ts.ranges = nil
+	ts.interrupt.Store(1)
+	buf.Reset()
+
+	err = c.Download("http://localhost/file", &buf)
+	qt.Check(t, qt.ErrorIs(err, io.ErrUnexpectedEOF))
+	qt.Check(t, qt.HasLen(ts.ranges, 1))
+}
+
+func TestDownloadNotResumable(t *testing.T) {
+	ts := &transferTestServer{noRanges: true}
+	ts.interrupt.Store(1)
+
+	c := newTransferTestServer(t, ts)
+
+	err := c.Download("http://localhost/file", io.Discard, Transfer{MaxResumes: 1})
+	qt.Check(t, qt.ErrorIs(err, ErrDownloadNotResumable))
+}
+
+func TestDownloadFile(t *testing.T) {
+	ts := &transferTestServer{}
+	c := newTransferTestServer(t, ts)
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	sum := sha256.Sum256(transferContent)
+
+	// Partially downloaded file is resumed.
+	qt.Assert(t, qt.IsNil(os.WriteFile(path, transferContent[:30000], 0o600)))
+
+	err := c.DownloadFile("http://localhost/file", path, Transfer{Checksum: sha256.New(), Sum: sum[:]})
+	qt.Assert(t, qt.IsNil(err))
+
+	data, err := os.ReadFile(path)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.IsTrue(bytes.Equal(data, transferContent)))
+
+	// Already downloaded file is not downloaded again.
+	err = c.DownloadFile("http://localhost/file", path, Transfer{Checksum: sha256.New(), Sum: sum[:]})
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.DeepEquals(ts.ranges, []string{"bytes=30000-", "bytes=100000-"}))
+}
+
+func TestDownloadFileIfRange(t *testing.T) {
+	ts := &transferTestServer{ifRange: true}
+	c := newTransferTestServer(t, ts)
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+
+	// File of the same size with unknown content is downloaded again.
+	qt.Assert(t, qt.IsNil(os.WriteFile(path, bytes.Repeat([]byte("x"), len(transferContent)), 0o600)))
+
+	err := c.DownloadFile("http://localhost/file", path)
+	qt.Assert(t, qt.IsNil(err))
+
+	data, err := os.ReadFile(path)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.IsTrue(bytes.Equal(data, transferContent)))
+
+	fi, err := os.Stat(path)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.IsTrue(fi.ModTime().Equal(transferModified)))
+
+	// Partially downloaded file with matching modification time is resumed.
+	qt.Assert(t, qt.IsNil(os.Truncate(path, 30000)))
+	qt.Assert(t, qt.IsNil(os.Chtimes(path, time.Time{}, transferModified)))
+
+	err = c.DownloadFile("http://localhost/file", path)
+	qt.Assert(t, qt.IsNil(err))
+
+	data, err = os.ReadFile(path)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.IsTrue(bytes.Equal(data, transferContent)))
+	qt.Check(t, qt.DeepEquals(ts.ranges, []string{"bytes=100000-", "bytes=30000-"}))
+}
+
+func TestDownloadFileRestart(t *testing.T) {
+	ts := &transferTestServer{noRanges: true}
+	c := newTransferTestServer(t, ts)
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	qt.Assert(t, qt.IsNil(os.WriteFile(path, []byte("stale content"), 0o600)))
+
+	err := c.DownloadFile("http://localhost/file", path)
+	qt.Assert(t, qt.IsNil(err))
+
+	data, err := os.ReadFile(path)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.IsTrue(bytes.Equal(data, transferContent)))
+}
+
+func TestDownloadFileChecksumMismatch(t *testing.T) {
+	ts := &transferTestServer{}
+	c := newTransferTestServer(t, ts)
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+
+	err := c.DownloadFile("http://localhost/file", path, Transfer{Checksum: sha256.New(), Sum: []byte{1, 2, 3}})
+	qt.Check(t, qt.ErrorAs(err, new(ChecksumMismatchError)))
+
+	_, err = os.Stat(path)
+	qt.Check(t, qt.ErrorIs(err, os.ErrNotExist))
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value string
+		start int64
+		total int64
+		ok    bool
+	}{
+		{"bytes 100-199/1000", 100, 1000, true},
+		{"bytes 0-99/*", 0, -1, true},
+		{"bytes */1000", -1, 1000, true},
+		{"items 0-1/2", 0, 0, false},
+		{"bytes 100/1000", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		start, total, ok := parseContentRange(tt.value)
+		qt.Check(t, qt.Equals(ok, tt.ok), qt.Commentf(tt.value))
+		qt.Check(t, qt.Equals(start, tt.start), qt.Commentf(tt.value))
+		qt.Check(t, qt.Equals(total, tt.total), qt.Commentf(tt.value))
+	}
+}
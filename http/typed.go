@@ -0,0 +1,94 @@
+package http
+
+import (
+	"github.com/valyala/fasthttp"
+)
+
+// GetAs performs a GET request to the specified URL and decodes the response into value of type T.
+func GetAs[T any](c Client, url string, opt ...RequestOption) (T, error) {
+	return doAs[T](c, fasthttp.MethodGet, url, nil, opt)
+}
+
+// PostAs performs a POST request to the specified URL with encoded body and decodes the response into value of type Resp.
+func PostAs[Req, Resp any](c Client, url string, body Req, opt ...RequestOption) (Resp, error) {
+	return doAs[Resp](c, fasthttp.MethodPost, url, body, opt)
+}
+
+// PutAs performs a PUT request to the specified URL with encoded body and decodes the response into value of type Resp.
+func PutAs[Req, Resp any](c Client, url string, body Req, opt ...RequestOption) (Resp, error) {
+	return doAs[Resp](c, fasthttp.MethodPut, url, body, opt)
+}
+
+// PatchAs performs a PATCH request to the specified URL with encoded body and decodes the response into value of type Resp.
+func PatchAs[Req, Resp any](c Client, url string, body Req, opt ...RequestOption) (Resp, error) {
+	return doAs[Resp](c, fasthttp.MethodPatch, url, body, opt)
+}
+
+// DeleteAs performs a DELETE request to the specified URL and decodes the response into value of type T.
+func DeleteAs[T any](c Client, url string, opt ...RequestOption) (T, error) {
+	return doAs[T](c, fasthttp.MethodDelete, url, nil, opt)
+}
+
+// doAs sends the request encoding the body with the request codec and decodes
+// the response with the codec registered for the response content type falling
+// back to the request codec.
+func doAs[T any](c Client, method, url string, body any, opt []RequestOption) (T, error) {
+	var v T
+
+	err := doDecode(c, method, url, body, opt, &v, nil)
+
+	return v, err
+}
+
+// doDecode sends the request and decodes the successful response into v.
+// If set, inspect is called with the request and response before the response is decoded.
+func doDecode(c Client, method, url string, body any, opt []RequestOption, v any, inspect func(req *Request, resp *Response)) error {
+	req := c.NewRequest()
+	defer c.ReleaseRequest(req)
+
+	if err := req.SetRequestURL(url); err != nil {
+		return err
+	}
+
+	req.apply(opt)
+	req.Header.SetMethod(method)
+
+	codec := req.codec
+	if codec == nil {
+		codec = CodecJSON
+	}
+
+	if body != nil {
+		buf, err := codec.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		if len(req.Header.ContentType()) == 0 {
+			req.Header.SetContentType(codec.ContentType())
+		}
+
+		req.SetBodyRaw(buf)
+	}
+
+	if len(req.Header.Peek(fasthttp.HeaderAccept)) == 0 {
+		req.Header.Set(fasthttp.HeaderAccept, codec.ContentType())
+	}
+
+	resp := c.NewResponse()
+	defer c.ReleaseResponse(resp)
+
+	if err := c.Do(req, resp); err != nil {
+		return err
+	}
+
+	if err := resp.Error(); err != nil {
+		return err
+	}
+
+	if inspect != nil {
+		inspect(req, resp)
+	}
+
+	return decodeResponse(resp, codec, v)
+}
@@ -9,10 +9,21 @@ func (a *App) initHTTPClient() {
 		return
 	}
 
-	a.httpClient = http.NewClient(
+	opts := []http.Option{
 		http.Instrumenter(a.Instrumenter()),
+		http.Logger{Logger: a.Log().Named("http")},
 		http.Context(a.bgctx),
-	)
+	}
+
+	if a.cache != nil {
+		opts = append(opts, http.Cache{Cache: a.cache})
+	}
+
+	if a.config != nil && a.config.Ready() && a.config.HTTP != nil {
+		opts = append(opts, &a.config.HTTP.Configuration)
+	}
+
+	a.httpClient = http.NewClient(opts...)
 }
 
 // HTTPClient returns the application HTTP client, optionally using a named configuration.
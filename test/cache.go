@@ -0,0 +1,384 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"azugo.io/core/cache"
+)
+
+// CacheOp is a fake cache operation type.
+type CacheOp string
+
+// Fake cache operation types.
+const (
+	CacheOpGet    CacheOp = "get"
+	CacheOpPop    CacheOp = "pop"
+	CacheOpSet    CacheOp = "set"
+	CacheOpDelete CacheOp = "delete"
+	CacheOpLoader CacheOp = "loader"
+	CacheOpPing   CacheOp = "ping"
+)
+
+// CacheCall is a recorded fake cache operation.
+type CacheCall struct {
+	// Op is the operation type.
+	Op CacheOp
+	// Key of the cache item.
+	Key string
+	// TTL of the item for set operation.
+	TTL time.Duration
+	// Hit is true if get or pop operation found the item in cache.
+	Hit bool
+	// Err returned by the operation.
+	Err error
+}
+
+// Clock is a manually controlled clock.
+type Clock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+// NewClock returns a new clock set to the specified time.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns current clock time.
+func (c *Clock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+// Set clock to the specified time.
+func (c *Clock) Set(now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = now
+}
+
+// Advance clock by the specified duration.
+func (c *Clock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+type fakeCacheItem[T any] struct {
+	value   T
+	expires time.Time
+}
+
+// FakeCache is a fake cache instance that records all operations and
+// allows injecting errors. Items expire based on the Clock time.
+//
+// FakeCache is safe for concurrent use.
+type FakeCache[T any] struct {
+	lock   sync.Mutex
+	clock  *Clock
+	ttl    time.Duration
+	loader cache.Loader
+	items  map[string]fakeCacheItem[T]
+	calls  []CacheCall
+	errs   map[CacheOp]error
+}
+
+// NewFakeCache creates a new fake cache instance.
+//
+// If clock is nil, a new clock set to the current time is used.
+func NewFakeCache[T any](clock *Clock) *FakeCache[T] {
+	if clock == nil {
+		clock = NewClock(time.Now())
+	}
+
+	return &FakeCache[T]{
+		clock: clock,
+		items: make(map[string]fakeCacheItem[T]),
+		errs:  make(map[CacheOp]error),
+	}
+}
+
+var _ cache.Instance[any] = (*FakeCache[any])(nil)
+
+// Clock returns the clock used for item expiration.
+func (c *FakeCache[T]) Clock() *Clock {
+	return c.clock
+}
+
+// SetDefaultTTL sets the default TTL for items without TTL specified.
+func (c *FakeCache[T]) SetDefaultTTL(ttl time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ttl = ttl
+}
+
+// SetLoader sets the loader that is called when item is not found in cache.
+//
+// Loader must not call back into the same fake cache instance.
+func (c *FakeCache[T]) SetLoader(loader cache.Loader) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.loader = loader
+}
+
+// SetError sets the error to be returned by all subsequent operations of
+// the specified type. Use nil to clear the error.
+func (c *FakeCache[T]) SetError(op CacheOp, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err == nil {
+		delete(c.errs, op)
+
+		return
+	}
+
+	c.errs[op] = err
+}
+
+// Calls returns all recorded operations.
+func (c *FakeCache[T]) Calls() []CacheCall {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return slices.Clone(c.calls)
+}
+
+// CallsOf returns recorded operations of the specified type.
+func (c *FakeCache[T]) CallsOf(op CacheOp) []CacheCall {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	calls := make([]CacheCall, 0, len(c.calls))
+
+	for _, call := range c.calls {
+		if call.Op == op {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// Keys returns sorted keys of all not expired items in cache.
+func (c *FakeCache[T]) Keys() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	keys := make([]string, 0, len(c.items))
+
+	for k := range c.items {
+		if _, ok := c.lookup(k); ok {
+			keys = append(keys, k)
+		}
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// Peek returns item value from cache without recording the operation.
+func (c *FakeCache[T]) Peek(key string) (T, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.lookup(key)
+}
+
+// Reset removes all items, recorded operations and injected errors.
+func (c *FakeCache[T]) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.items = make(map[string]fakeCacheItem[T])
+	c.calls = nil
+	c.errs = make(map[CacheOp]error)
+}
+
+func (c *FakeCache[T]) lookup(key string) (T, bool) {
+	item, ok := c.items[key]
+	if !ok {
+		var zero T
+
+		return zero, false
+	}
+
+	if !item.expires.IsZero() && !c.clock.Now().Before(item.expires) {
+		delete(c.items, key)
+
+		var zero T
+
+		return zero, false
+	}
+
+	return item.value, true
+}
+
+func (c *FakeCache[T]) record(call CacheCall) {
+	c.calls = append(c.calls, call)
+}
+
+func (c *FakeCache[T]) set(key string, value T, ttl time.Duration) {
+	item := fakeCacheItem[T]{value: value}
+	if ttl > 0 {
+		item.expires = c.clock.Now().Add(ttl)
+	}
+
+	c.items[key] = item
+}
+
+func itemTTL[T any](opts []cache.ItemOption[T]) time.Duration {
+	var ttl time.Duration
+
+	for _, o := range opts {
+		if t, ok := o.(cache.TTL[T]); ok {
+			ttl = time.Duration(t)
+		}
+	}
+
+	return ttl
+}
+
+// Get value from cache. If value is not found and loader is set, it will be
+// called and the value stored in cache.
+func (c *FakeCache[T]) Get(ctx context.Context, key string, opts ...cache.ItemOption[T]) (T, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var zero T
+
+	if err := c.errs[CacheOpGet]; err != nil {
+		c.record(CacheCall{Op: CacheOpGet, Key: key, Err: err})
+
+		return zero, err
+	}
+
+	if v, ok := c.lookup(key); ok {
+		c.record(CacheCall{Op: CacheOpGet, Key: key, Hit: true})
+
+		return v, nil
+	}
+
+	c.record(CacheCall{Op: CacheOpGet, Key: key})
+
+	if c.loader == nil {
+		return zero, nil
+	}
+
+	err := c.errs[CacheOpLoader]
+	if err != nil {
+		c.record(CacheCall{Op: CacheOpLoader, Key: key, Err: err})
+
+		return zero, err
+	}
+
+	raw, err := c.loader(ctx, key)
+	c.record(CacheCall{Op: CacheOpLoader, Key: key, Err: err})
+
+	if err != nil {
+		return zero, err
+	}
+
+	v, ok := raw.(T)
+	if !ok {
+		return zero, fmt.Errorf("invalid value from loader: %v", raw)
+	}
+
+	ttl := itemTTL(opts)
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+
+	c.set(key, v, ttl)
+
+	return v, nil
+}
+
+// Pop returns value from the cache and deletes it. If value is not found,
+// it will return cache.KeyNotFoundError error.
+func (c *FakeCache[T]) Pop(_ context.Context, key string) (T, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var zero T
+
+	if err := c.errs[CacheOpPop]; err != nil {
+		c.record(CacheCall{Op: CacheOpPop, Key: key, Err: err})
+
+		return zero, err
+	}
+
+	v, ok := c.lookup(key)
+	if !ok {
+		err := cache.KeyNotFoundError{Key: key}
+		c.record(CacheCall{Op: CacheOpPop, Key: key, Err: err})
+
+		return zero, err
+	}
+
+	delete(c.items, key)
+	c.record(CacheCall{Op: CacheOpPop, Key: key, Hit: true})
+
+	return v, nil
+}
+
+// Set value in cache.
+func (c *FakeCache[T]) Set(_ context.Context, key string, value T, opts ...cache.ItemOption[T]) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ttl := itemTTL(opts)
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+
+	if err := c.errs[CacheOpSet]; err != nil {
+		c.record(CacheCall{Op: CacheOpSet, Key: key, TTL: ttl, Err: err})
+
+		return err
+	}
+
+	c.set(key, value, ttl)
+	c.record(CacheCall{Op: CacheOpSet, Key: key, TTL: ttl})
+
+	return nil
+}
+
+// Delete value from cache.
+func (c *FakeCache[T]) Delete(_ context.Context, key string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.errs[CacheOpDelete]; err != nil {
+		c.record(CacheCall{Op: CacheOpDelete, Key: key, Err: err})
+
+		return err
+	}
+
+	delete(c.items, key)
+	c.record(CacheCall{Op: CacheOpDelete, Key: key})
+
+	return nil
+}
+
+// Ping returns error injected for ping operation.
+func (c *FakeCache[T]) Ping(_ context.Context) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	err := c.errs[CacheOpPing]
+	c.record(CacheCall{Op: CacheOpPing, Err: err})
+
+	return err
+}
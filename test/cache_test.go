@@ -0,0 +1,83 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"azugo.io/core/cache"
+
+	"github.com/go-quicktest/qt"
+)
+
+func TestFakeCache(t *testing.T) {
+	c := NewFakeCache[string](nil)
+
+	qt.Assert(t, qt.IsNil(c.Set(context.TODO(), "key", "value")))
+
+	val, err := c.Get(context.TODO(), "key")
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, "value"))
+
+	val, err = c.Pop(context.TODO(), "key")
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, "value"))
+
+	_, err = c.Pop(context.TODO(), "key")
+	qt.Check(t, qt.ErrorAs(err, new(cache.KeyNotFoundError)))
+
+	qt.Check(t, qt.DeepEquals(c.Calls(), []CacheCall{
+		{Op: CacheOpSet, Key: "key"},
+		{Op: CacheOpGet, Key: "key", Hit: true},
+		{Op: CacheOpPop, Key: "key", Hit: true},
+		{Op: CacheOpPop, Key: "key", Err: cache.KeyNotFoundError{Key: "key"}},
+	}))
+}
+
+func TestFakeCacheExpire(t *testing.T) {
+	clock := NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewFakeCache[string](clock)
+	c.SetDefaultTTL(time.Minute)
+
+	qt.Assert(t, qt.IsNil(c.Set(context.TODO(), "default", "value")))
+	qt.Assert(t, qt.IsNil(c.Set(context.TODO(), "item", "value", cache.TTL[string](time.Hour))))
+	qt.Check(t, qt.DeepEquals(c.Keys(), []string{"default", "item"}))
+
+	clock.Advance(time.Minute)
+	qt.Check(t, qt.DeepEquals(c.Keys(), []string{"item"}))
+
+	val, err := c.Get(context.TODO(), "default")
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, ""))
+
+	clock.Advance(time.Hour)
+	_, ok := c.Peek("item")
+	qt.Check(t, qt.IsFalse(ok))
+}
+
+func TestFakeCacheLoaderAndErrors(t *testing.T) {
+	c := NewFakeCache[string](nil)
+	c.SetLoader(func(_ context.Context, key string) (any, error) {
+		return "loaded " + key, nil
+	})
+
+	val, err := c.Get(context.TODO(), "key")
+	qt.Check(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(val, "loaded key"))
+	qt.Check(t, qt.HasLen(c.CallsOf(CacheOpLoader), 1))
+
+	errFail := errors.New("fail")
+	c.SetError(CacheOpSet, errFail)
+	qt.Check(t, qt.ErrorIs(c.Set(context.TODO(), "key", "value"), errFail))
+
+	val, _ = c.Peek("key")
+	qt.Check(t, qt.Equals(val, "loaded key"))
+
+	c.SetError(CacheOpSet, nil)
+	qt.Check(t, qt.IsNil(c.Set(context.TODO(), "key", "value")))
+
+	c.Reset()
+	qt.Check(t, qt.HasLen(c.Calls(), 0))
+	qt.Check(t, qt.HasLen(c.Keys(), 0))
+}
@@ -0,0 +1,360 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"azugo.io/core/http"
+
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+)
+
+// MockTransport is a programmable HTTP client transport that responds to
+// requests based on expectations.
+//
+// Use Option or http.Transport option to set it as the HTTP client transport.
+//
+// MockTransport is safe for concurrent use.
+type MockTransport struct {
+	lock         sync.Mutex
+	expectations []*MockExpectation
+	unexpected   []UnmatchedRequestError
+}
+
+// NewMockTransport creates a new mock HTTP transport.
+func NewMockTransport() *MockTransport {
+	return &MockTransport{}
+}
+
+var _ fasthttp.RoundTripper = (*MockTransport)(nil)
+
+// Option returns HTTP client option that sets the mock as client transport.
+func (m *MockTransport) Option() http.Option {
+	return http.Transport(m)
+}
+
+// Expect adds a new expectation. By default expectation matches any request
+// once and responds with 200 OK status code.
+func (m *MockTransport) Expect() *MockExpectation {
+	e := &MockExpectation{
+		status: fasthttp.StatusOK,
+		times:  1,
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.expectations = append(m.expectations, e)
+
+	return e
+}
+
+// AssertExpectations checks that all expectations have been met and that
+// there were no unexpected requests.
+func (m *MockTransport) AssertExpectations(t testing.TB) {
+	t.Helper()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, e := range m.expectations {
+		if !e.satisfied() {
+			t.Errorf("expected %s to be called %s, but was called %d times", e, e.timesString(), e.calls)
+		}
+	}
+
+	for _, u := range m.unexpected {
+		t.Error(u.Error())
+	}
+}
+
+// RoundTrip implements fasthttp.RoundTripper interface.
+func (m *MockTransport) RoundTrip(_ *fasthttp.HostClient, req *fasthttp.Request, resp *fasthttp.Response) (bool, error) {
+	e := m.match(req)
+	if e == nil {
+		return false, UnmatchedRequestError{Method: string(req.Header.Method()), URL: req.URI().String()}
+	}
+
+	if e.delay > 0 {
+		time.Sleep(e.delay)
+	}
+
+	if e.err != nil {
+		return false, e.err
+	}
+
+	resp.Reset()
+	resp.SetStatusCode(e.status)
+
+	for _, h := range e.headers {
+		resp.Header.Add(h[0], h[1])
+	}
+
+	resp.SetBody(e.respRaw)
+
+	return false, nil
+}
+
+// match returns the first expectation that matches the request and records the call.
+func (m *MockTransport) match(req *fasthttp.Request) *MockExpectation {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, e := range m.expectations {
+		if e.exhausted() || !e.ready() || !e.matches(req) {
+			continue
+		}
+
+		e.calls++
+
+		return e
+	}
+
+	m.unexpected = append(m.unexpected, UnmatchedRequestError{Method: string(req.Header.Method()), URL: req.URI().String()})
+
+	return nil
+}
+
+// MockExpectation describes the expected request and the response to return.
+//
+// Expectation must be fully configured before requests are sent.
+type MockExpectation struct {
+	method   string
+	path     string
+	query    [][2]string
+	matchHdr [][2]string
+	body     []byte
+	jsonBody any
+	hasBody  bool
+	isJSON   bool
+	matchFn  func(req *fasthttp.Request) bool
+	after    []*MockExpectation
+
+	status  int
+	headers [][2]string
+	respRaw []byte
+	delay   time.Duration
+	err     error
+
+	times int
+	calls int
+}
+
+// Method sets the expected request method.
+func (e *MockExpectation) Method(method string) *MockExpectation {
+	e.method = method
+
+	return e
+}
+
+// Path sets the expected request URL path.
+func (e *MockExpectation) Path(path string) *MockExpectation {
+	e.path = path
+
+	return e
+}
+
+// Query adds the expected request query parameter value.
+func (e *MockExpectation) Query(key, value string) *MockExpectation {
+	e.query = append(e.query, [2]string{key, value})
+
+	return e
+}
+
+// Header adds the expected request header value.
+func (e *MockExpectation) Header(key, value string) *MockExpectation {
+	e.matchHdr = append(e.matchHdr, [2]string{key, value})
+
+	return e
+}
+
+// Body sets the expected request body.
+func (e *MockExpectation) Body(body []byte) *MockExpectation {
+	e.body = body
+	e.hasBody = true
+	e.isJSON = false
+
+	return e
+}
+
+// JSONBody sets the expected request body that must be JSON equal to v.
+func (e *MockExpectation) JSONBody(v any) *MockExpectation {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("mock expectation: invalid JSON body: %v", err))
+	}
+
+	var jv any
+	_ = json.Unmarshal(buf, &jv)
+
+	e.jsonBody = jv
+	e.hasBody = true
+	e.isJSON = true
+
+	return e
+}
+
+// Match adds custom request matcher.
+func (e *MockExpectation) Match(fn func(req *fasthttp.Request) bool) *MockExpectation {
+	e.matchFn = fn
+
+	return e
+}
+
+// After requires the specified expectations to be met before this expectation matches.
+func (e *MockExpectation) After(other ...*MockExpectation) *MockExpectation {
+	e.after = append(e.after, other...)
+
+	return e
+}
+
+// Times sets how many times the expectation must be matched.
+func (e *MockExpectation) Times(n int) *MockExpectation {
+	e.times = n
+
+	return e
+}
+
+// AnyTimes allows the expectation to be matched any number of times including zero.
+func (e *MockExpectation) AnyTimes() *MockExpectation {
+	e.times = -1
+
+	return e
+}
+
+// Respond sets the response status code and body.
+//
+// Body can be nil, []byte or string. Any other value is encoded as JSON and
+// Content-Type header is set to application/json.
+func (e *MockExpectation) Respond(status int, body any) *MockExpectation {
+	e.status = status
+
+	switch b := body.(type) {
+	case nil:
+		e.respRaw = nil
+	case []byte:
+		e.respRaw = b
+	case string:
+		e.respRaw = []byte(b)
+	default:
+		buf, err := json.Marshal(b)
+		if err != nil {
+			panic(fmt.Sprintf("mock expectation: invalid JSON response: %v", err))
+		}
+
+		e.respRaw = buf
+		e.headers = append(e.headers, [2]string{fasthttp.HeaderContentType, "application/json"})
+	}
+
+	return e
+}
+
+// ResponseHeader adds the response header.
+func (e *MockExpectation) ResponseHeader(key, value string) *MockExpectation {
+	e.headers = append(e.headers, [2]string{key, value})
+
+	return e
+}
+
+// Delay sets the latency before the response is returned.
+func (e *MockExpectation) Delay(d time.Duration) *MockExpectation {
+	e.delay = d
+
+	return e
+}
+
+// Error sets the error to return instead of the response.
+func (e *MockExpectation) Error(err error) *MockExpectation {
+	e.err = err
+
+	return e
+}
+
+// String returns expectation description.
+func (e *MockExpectation) String() string {
+	method := e.method
+	if method == "" {
+		method = "*"
+	}
+
+	path := e.path
+	if path == "" {
+		path = "*"
+	}
+
+	return method + " " + path
+}
+
+func (e *MockExpectation) exhausted() bool {
+	return e.times >= 0 && e.calls >= e.times
+}
+
+func (e *MockExpectation) satisfied() bool {
+	return e.times < 0 || e.calls == e.times
+}
+
+func (e *MockExpectation) ready() bool {
+	for _, a := range e.after {
+		if a.calls == 0 || !a.satisfied() {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *MockExpectation) timesString() string {
+	if e.times == 1 {
+		return "once"
+	}
+
+	return fmt.Sprintf("%d times", e.times)
+}
+
+func (e *MockExpectation) matches(req *fasthttp.Request) bool {
+	if e.method != "" && !strings.EqualFold(e.method, string(req.Header.Method())) {
+		return false
+	}
+
+	if e.path != "" && e.path != string(req.URI().Path()) {
+		return false
+	}
+
+	args := req.URI().QueryArgs()
+	for _, q := range e.query {
+		if string(args.Peek(q[0])) != q[1] {
+			return false
+		}
+	}
+
+	for _, h := range e.matchHdr {
+		if string(req.Header.Peek(h[0])) != h[1] {
+			return false
+		}
+	}
+
+	if e.hasBody && !e.matchesBody(req.Body()) {
+		return false
+	}
+
+	return e.matchFn == nil || e.matchFn(req)
+}
+
+func (e *MockExpectation) matchesBody(body []byte) bool {
+	if !e.isJSON {
+		return bytes.Equal(e.body, body)
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(e.jsonBody, v)
+}
@@ -0,0 +1,104 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"azugo.io/core/http"
+
+	"github.com/go-quicktest/qt"
+	"github.com/valyala/fasthttp"
+)
+
+type mockUser struct {
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+func TestMockTransport(t *testing.T) {
+	m := NewMockTransport()
+
+	create := m.Expect().
+		Method(fasthttp.MethodPost).
+		Path("/users").
+		JSONBody(mockUser{Name: "John"}).
+		Respond(fasthttp.StatusCreated, mockUser{ID: 1, Name: "John"})
+	m.Expect().
+		Method(fasthttp.MethodGet).
+		Path("/users").
+		Query("page", "2").
+		After(create).
+		Times(2).
+		Respond(fasthttp.StatusOK, `[]`)
+
+	c := http.NewClient(m.Option())
+
+	_, err := c.Get("http://localhost/users?page=2")
+	qt.Check(t, qt.ErrorAs(err, new(UnmatchedRequestError)))
+
+	var user mockUser
+	qt.Assert(t, qt.IsNil(c.PostJSON("http://localhost/users", map[string]any{"name": "John"}, &user)))
+	qt.Check(t, qt.DeepEquals(user, mockUser{ID: 1, Name: "John"}))
+
+	for range 2 {
+		body, err := c.Get("http://localhost/users?page=2")
+		qt.Assert(t, qt.IsNil(err))
+		qt.Check(t, qt.Equals(string(body), "[]"))
+	}
+
+	ft := &fakeT{}
+	m.AssertExpectations(ft)
+	qt.Check(t, qt.DeepEquals(ft.errors, []string{"unexpected request GET http://localhost/users?page=2"}))
+}
+
+func TestMockTransportUnmetExpectations(t *testing.T) {
+	m := NewMockTransport()
+	m.Expect().Method(fasthttp.MethodDelete).Path("/users/1").Respond(fasthttp.StatusNoContent, nil)
+
+	ft := &fakeT{}
+	m.AssertExpectations(ft)
+	qt.Check(t, qt.DeepEquals(ft.errors, []string{"expected DELETE /users/1 to be called once, but was called 0 times"}))
+}
+
+var errMockConnection = errors.New("connection refused")
+
+func TestMockTransportErrorAndDelay(t *testing.T) {
+	m := NewMockTransport()
+	m.Expect().Path("/fail").Error(errMockConnection)
+	m.Expect().Path("/slow").Delay(50*time.Millisecond).Respond(fasthttp.StatusOK, "ok")
+
+	c := http.NewClient(m.Option())
+
+	_, err := c.Get("http://localhost/fail")
+	qt.Check(t, qt.ErrorIs(err, errMockConnection))
+
+	start := time.Now()
+
+	body, err := c.Get("http://localhost/slow")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), "ok"))
+	qt.Check(t, qt.IsTrue(time.Since(start) >= 50*time.Millisecond))
+
+	m.AssertExpectations(t)
+}
+
+type fakeT struct {
+	testing.TB
+
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Error(args ...any) {
+	for _, a := range args {
+		s, _ := a.(string)
+		t.errors = append(t.errors, s)
+	}
+}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,397 @@
+package test
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"azugo.io/core/http"
+
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+	"go.yaml.in/yaml/v3"
+)
+
+// RecorderMode defines how the recorder handles requests.
+type RecorderMode int
+
+const (
+	// RecorderReplay replays interactions from the cassette file.
+	RecorderReplay RecorderMode = iota
+	// RecorderRecord sends all requests to the server and records interactions
+	// replacing the existing cassette file content.
+	RecorderRecord
+	// RecorderReplayOrRecord replays matching interactions from the cassette
+	// file and records the missing ones.
+	RecorderReplayOrRecord
+)
+
+// CassetteRequest is the recorded HTTP request.
+type CassetteRequest struct {
+	Method     string              `json:"method" yaml:"method"`
+	URL        string              `json:"url" yaml:"url"`
+	Headers    map[string][]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body       string              `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyBase64 bool                `json:"body_base64,omitempty" yaml:"body_base64,omitempty"`
+}
+
+// CassetteResponse is the recorded HTTP response.
+type CassetteResponse struct {
+	Status     int                 `json:"status" yaml:"status"`
+	Headers    map[string][]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body       string              `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyBase64 bool                `json:"body_base64,omitempty" yaml:"body_base64,omitempty"`
+}
+
+// CassetteInteraction is the recorded HTTP request and response pair.
+type CassetteInteraction struct {
+	Request  CassetteRequest  `json:"request" yaml:"request"`
+	Response CassetteResponse `json:"response" yaml:"response"`
+}
+
+// Cassette holds recorded HTTP interactions.
+type Cassette struct {
+	Interactions []*CassetteInteraction `json:"interactions" yaml:"interactions"`
+}
+
+// RecorderConfig configures the HTTP traffic recorder.
+type RecorderConfig struct {
+	// Path to the cassette file. Files with .yaml or .yml extension are stored
+	// in YAML format, all other in JSON format.
+	Path string
+	// Mode of the recorder (defaults to RecorderReplay).
+	Mode RecorderMode
+	// Strict mode fails requests that do not match any recorded interaction
+	// in replay mode. Otherwise unmatched requests are sent to the server.
+	Strict bool
+	// MatchHeaders is the list of request headers that must match in addition
+	// to the method, URL and body.
+	MatchHeaders []string
+	// IgnoreBody disables request body matching.
+	IgnoreBody bool
+	// RedactHeaders is the list of additional headers to redact.
+	RedactHeaders []string
+	// RedactQuery is the list of additional query parameters to redact.
+	RedactQuery []string
+	// RedactFields is the list of additional JSON and form body fields to redact.
+	RedactFields []string
+	// Transport used to send requests to the server (defaults to fasthttp.DefaultTransport).
+	Transport fasthttp.RoundTripper
+}
+
+// UnmatchedRequestError is returned when request does not match any recorded
+// interaction in strict replay mode or any mock transport expectation.
+type UnmatchedRequestError struct {
+	Method string
+	URL    string
+}
+
+func (e UnmatchedRequestError) Error() string {
+	return fmt.Sprintf("unexpected request %s %s", e.Method, e.URL)
+}
+
+// Recorder is an HTTP client transport that records HTTP interactions to the
+// cassette file and replays them in tests.
+//
+// Use Option to set the recorder as the HTTP client transport. Recorded
+// interactions are written to the cassette file only when Save is called.
+// Secrets are redacted using http.Redactor the same way as in the HTTP client
+// request logs.
+//
+// Recorder is safe for concurrent use.
+type Recorder struct {
+	config   RecorderConfig
+	redactor *http.Redactor
+
+	lock     sync.Mutex
+	cassette Cassette
+	used     []bool
+	changed  bool
+}
+
+// NewRecorder creates a new HTTP traffic recorder loading the cassette file
+// if it exists. In replay mode the cassette file must exist.
+func NewRecorder(config RecorderConfig) (*Recorder, error) {
+	if config.Transport == nil {
+		config.Transport = fasthttp.DefaultTransport
+	}
+
+	r := &Recorder{
+		config:   config,
+		redactor: http.NewRedactor(config.RedactHeaders, config.RedactQuery, config.RedactFields),
+	}
+
+	if config.Mode == RecorderRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(config.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && config.Mode == RecorderReplayOrRecord {
+			return r, nil
+		}
+
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	if r.yaml() {
+		err = yaml.Unmarshal(data, &r.cassette)
+	} else {
+		err = json.Unmarshal(data, &r.cassette)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cassette: %w", err)
+	}
+
+	r.used = make([]bool, len(r.cassette.Interactions))
+
+	return r, nil
+}
+
+var _ fasthttp.RoundTripper = (*Recorder)(nil)
+
+// Option returns HTTP client option that sets the recorder as client transport.
+func (r *Recorder) Option() http.Option {
+	return http.Transport(r)
+}
+
+// Cassette returns a copy of recorded interactions.
+func (r *Recorder) Cassette() Cassette {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return Cassette{Interactions: slices.Clone(r.cassette.Interactions)}
+}
+
+// Save writes the cassette file if new interactions have been recorded.
+func (r *Recorder) Save() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.changed {
+		return nil
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+
+	if r.yaml() {
+		data, err = yaml.Marshal(&r.cassette)
+	} else {
+		data, err = json.MarshalIndent(&r.cassette, "", "  ")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.config.Path), 0o755); err != nil { //nolint:gosec
+		return err
+	}
+
+	if err := os.WriteFile(r.config.Path, data, 0o644); err != nil { //nolint:gosec
+		return err
+	}
+
+	r.changed = false
+
+	return nil
+}
+
+func (r *Recorder) yaml() bool {
+	ext := strings.ToLower(filepath.Ext(r.config.Path))
+
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// RoundTrip implements fasthttp.RoundTripper interface.
+func (r *Recorder) RoundTrip(hc *fasthttp.HostClient, req *fasthttp.Request, resp *fasthttp.Response) (bool, error) {
+	recorded := r.request(req)
+
+	if r.config.Mode != RecorderRecord {
+		if i, ok := r.match(recorded); ok {
+			return false, r.replay(i, resp)
+		}
+
+		if r.config.Mode == RecorderReplay && r.config.Strict {
+			return false, UnmatchedRequestError{Method: recorded.Method, URL: recorded.URL}
+		}
+	}
+
+	retry, err := r.config.Transport.RoundTrip(hc, req, resp)
+	if err != nil || r.config.Mode == RecorderReplay {
+		return retry, err
+	}
+
+	interaction := &CassetteInteraction{
+		Request:  recorded,
+		Response: r.response(resp),
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.used = append(r.used, true)
+	r.changed = true
+
+	return false, nil
+}
+
+// match returns the index of the first unused matching interaction or the
+// last matching interaction if all have already been used.
+func (r *Recorder) match(req CassetteRequest) (int, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	last := -1
+
+	for i, interaction := range r.cassette.Interactions {
+		if !r.matches(interaction.Request, req) {
+			continue
+		}
+
+		if !r.used[i] {
+			r.used[i] = true
+
+			return i, true
+		}
+
+		last = i
+	}
+
+	return last, last >= 0
+}
+
+func (r *Recorder) matches(recorded, req CassetteRequest) bool {
+	if !strings.EqualFold(recorded.Method, req.Method) || recorded.URL != req.URL {
+		return false
+	}
+
+	for _, h := range r.config.MatchHeaders {
+		if !slices.Equal(headerValues(recorded.Headers, h), headerValues(req.Headers, h)) {
+			return false
+		}
+	}
+
+	return r.config.IgnoreBody || (recorded.Body == req.Body && recorded.BodyBase64 == req.BodyBase64)
+}
+
+func headerValues(headers map[string][]string, key string) []string {
+	for k, v := range headers {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func (r *Recorder) replay(i int, resp *fasthttp.Response) error {
+	r.lock.Lock()
+	recorded := r.cassette.Interactions[i].Response
+	r.lock.Unlock()
+
+	body, err := decodeBody(recorded.Body, recorded.BodyBase64)
+	if err != nil {
+		return err
+	}
+
+	resp.Reset()
+	resp.SetStatusCode(recorded.Status)
+
+	for k, values := range recorded.Headers {
+		for _, v := range values {
+			resp.Header.Add(k, v)
+		}
+	}
+
+	resp.SetBody(body)
+
+	return nil
+}
+
+// request returns redacted request for recording and matching.
+func (r *Recorder) request(req *fasthttp.Request) CassetteRequest {
+	headers := make(map[string][]string)
+
+	for k, v := range req.Header.All() {
+		key := string(k)
+		headers[key] = append(headers[key], r.redactor.Header(k, v))
+	}
+
+	body, b64 := encodeBody(r.body(req.Header.ContentType(), req.Body()))
+
+	return CassetteRequest{
+		Method:     string(req.Header.Method()),
+		URL:        r.redactor.URL(req.URI()),
+		Headers:    headers,
+		Body:       body,
+		BodyBase64: b64,
+	}
+}
+
+// response returns redacted response for recording.
+func (r *Recorder) response(resp *fasthttp.Response) CassetteResponse {
+	headers := make(map[string][]string)
+
+	for k, v := range resp.Header.All() {
+		key := string(k)
+
+		// Body is stored uncompressed so content encoding and length headers are not recorded.
+		if strings.EqualFold(key, fasthttp.HeaderContentEncoding) || strings.EqualFold(key, fasthttp.HeaderContentLength) {
+			continue
+		}
+
+		headers[key] = append(headers[key], r.redactor.Header(k, v))
+	}
+
+	raw, err := resp.BodyUncompressed()
+	if err != nil {
+		raw = resp.Body()
+	}
+
+	body, b64 := encodeBody(r.body(resp.Header.ContentType(), raw))
+
+	return CassetteResponse{
+		Status:     resp.StatusCode(),
+		Headers:    headers,
+		Body:       body,
+		BodyBase64: b64,
+	}
+}
+
+// body returns body with redacted JSON or form fields.
+func (r *Recorder) body(contentType, body []byte) []byte {
+	redacted, err := r.redactor.Body(contentType, body)
+	if err != nil {
+		return body
+	}
+
+	return redacted
+}
+
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+func decodeBody(body string, b64 bool) ([]byte, error) {
+	if !b64 {
+		return []byte(body), nil
+	}
+
+	return base64.StdEncoding.DecodeString(body)
+}
@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"azugo.io/core/http"
+
+	"github.com/go-quicktest/qt"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func newTestServer(t *testing.T, handler fasthttp.RequestHandler) http.Option {
+	t.Helper()
+
+	ln := fasthttputil.NewInmemoryListener()
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		_ = fasthttp.Serve(ln, handler)
+	}()
+
+	return http.DialContextFunc(func(context.Context, string, string) (net.Conn, error) {
+		return ln.Dial()
+	})
+}
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+
+	calls := 0
+	dial := newTestServer(t, func(ctx *fasthttp.RequestCtx) {
+		calls++
+
+		ctx.SetContentType("application/json")
+		ctx.SetBodyString(`{"name":"John","token":"secret"}`)
+	})
+
+	rec, err := NewRecorder(RecorderConfig{
+		Path:         path,
+		Mode:         RecorderRecord,
+		RedactQuery:  []string{"api_key"},
+		RedactFields: []string{"token"},
+	})
+	qt.Assert(t, qt.IsNil(err))
+
+	c := http.NewClient(dial, rec.Option())
+
+	body, err := c.Get("http://localhost/users?api_key=123", http.WithHeader(fasthttp.HeaderAuthorization, "Bearer abc"))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(body), `{"name":"John","token":"secret"}`))
+	qt.Assert(t, qt.IsNil(rec.Save()))
+
+	cassette := rec.Cassette()
+	qt.Assert(t, qt.HasLen(cassette.Interactions, 1))
+	qt.Check(t, qt.Equals(cassette.Interactions[0].Request.URL, "http://localhost/users?api_key=%5BREDACTED%5D"))
+	qt.Check(t, qt.DeepEquals(cassette.Interactions[0].Request.Headers[fasthttp.HeaderAuthorization], []string{http.RedactedValue}))
+	qt.Check(t, qt.Equals(cassette.Interactions[0].Response.Body, `{"name":"John","token":"[REDACTED]"}`))
+
+	rec, err = NewRecorder(RecorderConfig{
+		Path:         path,
+		Strict:       true,
+		RedactQuery:  []string{"api_key"},
+		RedactFields: []string{"token"},
+	})
+	qt.Assert(t, qt.IsNil(err))
+
+	c = http.NewClient(dial, rec.Option())
+
+	var v struct {
+		Name string `json:"name"`
+	}
+
+	qt.Assert(t, qt.IsNil(c.GetJSON("http://localhost/users?api_key=456", &v)))
+	qt.Check(t, qt.Equals(v.Name, "John"))
+	qt.Check(t, qt.Equals(calls, 1))
+
+	_, err = c.Get("http://localhost/other")
+	qt.Check(t, qt.ErrorAs(err, new(UnmatchedRequestError)))
+	qt.Check(t, qt.Equals(calls, 1))
+}
+
+func TestRecorderMatchBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	dial := newTestServer(t, func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBody(ctx.PostBody())
+	})
+
+	rec, err := NewRecorder(RecorderConfig{Path: path, Mode: RecorderReplayOrRecord})
+	qt.Assert(t, qt.IsNil(err))
+
+	c := http.NewClient(dial, rec.Option())
+
+	for _, body := range []string{"one", "two", "one"} {
+		resp, err := c.Post("http://localhost/echo", []byte(body))
+		qt.Assert(t, qt.IsNil(err))
+		qt.Check(t, qt.Equals(string(resp), body))
+	}
+
+	qt.Check(t, qt.HasLen(rec.Cassette().Interactions, 2))
+	qt.Assert(t, qt.IsNil(rec.Save()))
+
+	rec, err = NewRecorder(RecorderConfig{Path: path, Strict: true})
+	qt.Assert(t, qt.IsNil(err))
+
+	c = http.NewClient(rec.Option())
+
+	resp, err := c.Post("http://localhost/echo", []byte("two"))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(string(resp), "two"))
+
+	_, err = c.Post("http://localhost/echo", []byte("three"))
+	qt.Check(t, qt.ErrorAs(err, new(UnmatchedRequestError)))
+}
+
+func TestRecorderDefaultRedaction(t *testing.T) {
+	dial := newTestServer(t, func(ctx *fasthttp.RequestCtx) {
+		ctx.SetContentType("application/json")
+		ctx.SetBodyString(`{"access_token":"secret","token_type":"bearer"}`)
+	})
+
+	rec, err := NewRecorder(RecorderConfig{
+		Path: filepath.Join(t.TempDir(), "cassette.json"),
+		Mode: RecorderRecord,
+	})
+	qt.Assert(t, qt.IsNil(err))
+
+	c := http.NewClient(dial, rec.Option())
+
+	_, err = c.PostForm("http://localhost/token?access_token=123", map[string][]string{
+		"client_secret": {"secret"},
+	})
+	qt.Assert(t, qt.IsNil(err))
+
+	cassette := rec.Cassette()
+	qt.Assert(t, qt.HasLen(cassette.Interactions, 1))
+	qt.Check(t, qt.Equals(cassette.Interactions[0].Request.URL, "http://localhost/token?access_token=%5BREDACTED%5D"))
+	qt.Check(t, qt.Equals(cassette.Interactions[0].Request.Body, "client_secret=%5BREDACTED%5D"))
+	qt.Check(t, qt.Equals(cassette.Interactions[0].Response.Body, `{"access_token":"[REDACTED]","token_type":"bearer"}`))
+}
@@ -0,0 +1,78 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type spanContextKey struct{}
+
+type requestIDKey struct{}
+
+// ContextWithSpan returns a copy of the context with the span context.
+func ContextWithSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanFromContext returns the span context stored in the context.
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+
+	return sc, ok && sc.IsValid()
+}
+
+// ContextWithRequestID returns a copy of the context with the request ID.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in the context.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+
+	id, ok := ctx.Value(requestIDKey{}).(string)
+
+	return id, ok && id != ""
+}
+
+// NewRequestID returns a new random request ID.
+func NewRequestID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+
+	return hex.EncodeToString(id[:])
+}
+
+// HeaderGetter is the interface to read request header values
+// (e.g. *fasthttp.RequestHeader).
+type HeaderGetter interface {
+	Peek(key string) []byte
+}
+
+// Extract returns a copy of the context with the span context and the request
+// ID read from the incoming request headers.
+//
+// If request does not have a valid traceparent header, the new trace is started.
+// If request does not have X-Request-ID header, the new request ID is generated.
+func Extract(ctx context.Context, h HeaderGetter) context.Context {
+	sc, err := ParseTraceParent(string(h.Peek(HeaderTraceParent)))
+	if err != nil {
+		sc = NewSpanContext()
+	} else {
+		sc.TraceState = string(h.Peek(HeaderTraceState))
+	}
+
+	id := string(h.Peek(HeaderRequestID))
+	if id == "" {
+		id = NewRequestID()
+	}
+
+	return ContextWithRequestID(ContextWithSpan(ctx, sc), id)
+}
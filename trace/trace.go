@@ -0,0 +1,149 @@
+// Package trace provides W3C trace context and request ID propagation helpers.
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Propagation header names.
+const (
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+	HeaderRequestID   = "X-Request-ID"
+)
+
+// FlagSampled is the trace flag that indicates that the caller may have recorded trace data.
+const FlagSampled byte = 0x01
+
+const traceParentVersion = "00"
+
+// ErrInvalidTraceParent is returned when traceparent header value can not be parsed.
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+// TraceID is a W3C trace context trace identifier.
+type TraceID [16]byte
+
+// NewTraceID returns a new random trace ID.
+func NewTraceID() TraceID {
+	var id TraceID
+	_, _ = rand.Read(id[:])
+
+	return id
+}
+
+// IsValid reports if trace ID is not all zeros.
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// String returns lower case hex encoded trace ID.
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID is a W3C trace context parent (span) identifier.
+type SpanID [8]byte
+
+// NewSpanID returns a new random span ID.
+func NewSpanID() SpanID {
+	var id SpanID
+	_, _ = rand.Read(id[:])
+
+	return id
+}
+
+// IsValid reports if span ID is not all zeros.
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// String returns lower case hex encoded span ID.
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext holds W3C trace context of the span.
+type SpanContext struct {
+	// TraceID is the identifier of the whole trace.
+	TraceID TraceID
+	// SpanID is the identifier of the span.
+	SpanID SpanID
+	// Flags are the trace flags.
+	Flags byte
+	// TraceState is the vendor specific trace state.
+	TraceState string
+}
+
+// NewSpanContext returns a span context of the new trace.
+func NewSpanContext() SpanContext {
+	return SpanContext{
+		TraceID: NewTraceID(),
+		SpanID:  NewSpanID(),
+	}
+}
+
+// IsValid reports if both trace and span IDs are valid.
+func (s SpanContext) IsValid() bool {
+	return s.TraceID.IsValid() && s.SpanID.IsValid()
+}
+
+// IsSampled reports if sampled flag is set.
+func (s SpanContext) IsSampled() bool {
+	return s.Flags&FlagSampled != 0
+}
+
+// Child returns span context of the new child span in the same trace.
+func (s SpanContext) Child() SpanContext {
+	s.SpanID = NewSpanID()
+
+	return s
+}
+
+// TraceParent returns traceparent header value.
+func (s SpanContext) TraceParent() string {
+	return traceParentVersion + "-" + s.TraceID.String() + "-" + s.SpanID.String() + "-" + hex.EncodeToString([]byte{s.Flags})
+}
+
+// ParseTraceParent parses traceparent header value.
+func ParseTraceParent(v string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+
+	// Version 00 must have exactly 4 fields, future versions can have more.
+	if parts[0] == traceParentVersion && len(parts) != 4 {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+
+	var (
+		sc    SpanContext
+		flags [1]byte
+	)
+
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) || !decodeHex(flags[:], parts[3]) {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+
+	sc.Flags = flags[0]
+
+	return sc, nil
+}
+
+// decodeHex decodes lower case hex string into dst that must have exact length.
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return false
+	}
+
+	_, err := hex.Decode(dst, []byte(s))
+
+	return err == nil
+}
@@ -0,0 +1,71 @@
+package trace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-quicktest/qt"
+	"github.com/valyala/fasthttp"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	sc, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(sc.TraceID.String(), "4bf92f3577b34da6a3ce929d0e0e4736"))
+	qt.Check(t, qt.Equals(sc.SpanID.String(), "00f067aa0ba902b7"))
+	qt.Check(t, qt.IsTrue(sc.IsSampled()))
+	qt.Check(t, qt.Equals(sc.TraceParent(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+
+	for _, v := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, err := ParseTraceParent(v)
+		qt.Check(t, qt.ErrorIs(err, ErrInvalidTraceParent), qt.Commentf("traceparent %q", v))
+	}
+
+	_, err = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	qt.Check(t, qt.IsNil(err))
+}
+
+func TestSpanContextChild(t *testing.T) {
+	sc := NewSpanContext()
+	child := sc.Child()
+
+	qt.Check(t, qt.Equals(child.TraceID, sc.TraceID))
+	qt.Check(t, qt.Not(qt.Equals(child.SpanID, sc.SpanID)))
+	qt.Check(t, qt.IsTrue(child.IsValid()))
+}
+
+func TestExtract(t *testing.T) {
+	h := &fasthttp.RequestHeader{}
+	h.Set(HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.Set(HeaderTraceState, "congo=t61rcWkgMzE")
+	h.Set(HeaderRequestID, "req-1")
+
+	ctx := Extract(context.Background(), h)
+
+	sc, ok := SpanFromContext(ctx)
+	qt.Assert(t, qt.IsTrue(ok))
+	qt.Check(t, qt.Equals(sc.TraceID.String(), "4bf92f3577b34da6a3ce929d0e0e4736"))
+	qt.Check(t, qt.Equals(sc.TraceState, "congo=t61rcWkgMzE"))
+
+	id, ok := RequestIDFromContext(ctx)
+	qt.Check(t, qt.IsTrue(ok))
+	qt.Check(t, qt.Equals(id, "req-1"))
+
+	ctx = Extract(context.Background(), &fasthttp.RequestHeader{})
+
+	sc, ok = SpanFromContext(ctx)
+	qt.Check(t, qt.IsTrue(ok))
+	qt.Check(t, qt.IsTrue(sc.IsValid()))
+
+	id, ok = RequestIDFromContext(ctx)
+	qt.Check(t, qt.IsTrue(ok))
+	qt.Check(t, qt.HasLen(id, 32))
+}